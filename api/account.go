@@ -0,0 +1,89 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"spendon/models"
+	"spendon/storage"
+	"time"
+)
+
+func (server *Server) deleteAccount(rw http.ResponseWriter, r *http.Request) {
+	dbLogin := LoginFrom(r.Context())
+
+	deletionRequest := models.AccountDeletionRequest{}
+	err := decodeBody(r, &deletionRequest)
+	if err != nil {
+		writeBodyError(rw, err)
+		return
+	}
+	confirmedLogin, err := storage.GetUserByPassword(deletionRequest.Password, dbLogin.Login)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		fmt.Println("User fetching error:", err)
+		writeServerError(rw)
+		return
+	}
+	if err != nil || confirmedLogin.Id != dbLogin.Id {
+		writeError(rw, models.UnauthorizedCode, "User or password are incorrect!")
+		return
+	}
+	deletion, err := storage.ScheduleAccountDeletion(dbLogin.Id, time.Now().UTC().Add(server.settings.AccountDeletionGrace()))
+	if errors.Is(err, storage.ErrDuplicate) {
+		writeError(rw, models.ConflictCode, "Account deletion is already scheduled")
+		return
+	}
+	if err != nil {
+		fmt.Println("Account deletion error:", err)
+		writeServerError(rw)
+		return
+	}
+	writeJSON(rw, http.StatusAccepted, deletion)
+}
+
+func (server *Server) getAccountDeletion(rw http.ResponseWriter, r *http.Request) {
+	dbLogin := LoginFrom(r.Context())
+
+	deletion, err := storage.GetPendingAccountDeletion(dbLogin.Id)
+	if errors.Is(err, storage.ErrNotFound) {
+		writeError(rw, models.NotFoundCode, "Account deletion is not scheduled")
+		return
+	}
+	if err != nil {
+		fmt.Println("Fetching account deletion error:", err)
+		writeServerError(rw)
+		return
+	}
+	writeJSON(rw, http.StatusOK, deletion)
+}
+
+func (server *Server) cancelAccountDeletion(rw http.ResponseWriter, r *http.Request) {
+	dbLogin := LoginFrom(r.Context())
+
+	err := storage.CancelAccountDeletion(dbLogin.Id)
+	if errors.Is(err, storage.ErrNotFound) {
+		writeError(rw, models.NotFoundCode, "Account deletion is not scheduled")
+		return
+	}
+	if err != nil {
+		fmt.Println("Cancelling account deletion error:", err)
+		writeServerError(rw)
+		return
+	}
+}
+
+func (server *Server) getAccountDeletions(rw http.ResponseWriter, r *http.Request) {
+	dbLogin := LoginFrom(r.Context())
+	if !server.settings.IsAdmin(dbLogin.Login) {
+		writeError(rw, models.ForbiddenCode, "Only administrators can see account deletions")
+		return
+	}
+
+	deletions, err := storage.GetAccountDeletions()
+	if err != nil {
+		fmt.Println("Fetching account deletions error:", err)
+		writeServerError(rw)
+		return
+	}
+	writeJSON(rw, http.StatusOK, deletions)
+}
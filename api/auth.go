@@ -0,0 +1,121 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"spendon/models"
+	"spendon/storage"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+func (server *Server) login(rw http.ResponseWriter, r *http.Request) {
+	loginModel := models.Login{}
+	if !server.readRequest(rw, r, &loginModel) {
+		return
+	}
+
+	if server.settings.SigningSecret == "" {
+		writeError(rw, models.NotFoundCode, "Secret is not set on the server!")
+		return
+	}
+
+	dbLogin, err := storage.GetUserByPassword(loginModel.Password, loginModel.UserName)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		fmt.Println("User fetching error:", err)
+		writeServerError(rw)
+		return
+	}
+
+	if err != nil || dbLogin.Login != loginModel.UserName {
+		writeError(rw, models.UnauthorizedCode, "User or password are incorrect!")
+		return
+	}
+	expireDate := time.Now().Add(7 * 24 * time.Hour)
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"user": dbLogin.Login,
+		"exp":  expireDate.Unix(),
+	})
+	secretKey := []byte(server.settings.SigningSecret)
+	tokenString, err := token.SignedString(secretKey)
+	if err != nil {
+		fmt.Println("Token create error!:", err)
+		writeServerError(rw)
+		return
+	}
+	loginResult := models.LoginResult{
+		Token:      tokenString,
+		ExpireDate: expireDate,
+	}
+	writeJSON(rw, http.StatusOK, loginResult)
+}
+
+func (server *Server) checkAuth(rw http.ResponseWriter, r *http.Request) {
+	dbLogin := LoginFrom(r.Context())
+	rw.WriteHeader(http.StatusOK)
+	fmt.Println("User found: " + dbLogin.Login)
+}
+
+func (server *Server) register(rw http.ResponseWriter, r *http.Request) {
+	registerModel := models.RegisterModel{}
+	if !server.readRequest(rw, r, &registerModel) {
+		return
+	}
+
+	inserted, err := storage.AddUser(&registerModel)
+	if err != nil {
+		fmt.Println("Register user error:", err)
+		writeServerError(rw)
+		return
+	}
+	if !inserted {
+		writeError(rw, models.ConflictCode, "This login is already taken!")
+		return
+	}
+	expireDate := time.Now().Add(7 * 24 * time.Hour)
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"user": registerModel.Login,
+		"exp":  expireDate.Unix(),
+	})
+	secretKey := []byte(server.settings.SigningSecret)
+	tokenString, err := token.SignedString(secretKey)
+	if err != nil {
+		fmt.Println("Token create error!:", err)
+		writeServerError(rw)
+		return
+	}
+	loginResult := models.LoginResult{
+		Token:      tokenString,
+		ExpireDate: expireDate,
+	}
+	writeJSON(rw, http.StatusOK, loginResult)
+}
+
+func (server *Server) validateLoginToken(token string) (*models.DbLogin, error) {
+	jwtToken, err := jwt.ParseWithClaims(token, &jwt.MapClaims{}, func(t *jwt.Token) (interface{}, error) {
+		return []byte(server.settings.SigningSecret), nil
+	})
+	if err != nil {
+		return &models.DbLogin{}, err
+	}
+	claims, ok := jwtToken.Claims.(*jwt.MapClaims)
+	if !ok {
+		return &models.DbLogin{}, fmt.Errorf("validation error")
+	}
+	validationError := claims.Valid()
+	if validationError != nil {
+		return &models.DbLogin{}, validationError
+	}
+	userName := (*claims)["user"]
+	userNameStr := fmt.Sprintf("%v", userName)
+	dbLogin, err := storage.GetUserByLogin(userNameStr)
+	if err != nil {
+		return dbLogin, err
+	}
+	if userNameStr != dbLogin.Login {
+		return dbLogin, fmt.Errorf("user not found")
+	}
+	return dbLogin, nil
+}
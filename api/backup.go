@@ -0,0 +1,67 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"spendon/backup"
+	"spendon/models"
+	"spendon/storage"
+	"time"
+)
+
+func (server *Server) backupAccount(rw http.ResponseWriter, r *http.Request) {
+	dbLogin := LoginFrom(r.Context())
+
+	accountBackup, err := storage.GetBackup(dbLogin.Id, dbLogin.Login)
+	if err != nil {
+		fmt.Println("Backup error:", err)
+		writeServerError(rw)
+		return
+	}
+	rw.Header().Set("Content-Type", "application/zip")
+	rw.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"spendon-backup-%s.zip\"", time.Now().Format("2006-01-02")))
+	filters := models.FilterBatch{}
+	err = backup.Write(rw, accountBackup, func(visit func(transaction *models.Transaction) error) error {
+		return storage.StreamTransactions(dbLogin.Id, &filters, visit)
+	})
+	if err != nil {
+		fmt.Println("Backup error:", err)
+	}
+}
+
+func (server *Server) restoreAccount(rw http.ResponseWriter, r *http.Request) {
+	dbLogin := LoginFrom(r.Context())
+
+	err := r.ParseMultipartForm(32 << 20)
+	if err != nil {
+		writeBodyError(rw, err)
+		return
+	}
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		writeValidationError(rw, &models.FieldError{Field: "file", Message: "backup file is required"})
+		return
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+	accountBackup, err := backup.Read(file, header.Size)
+	if err != nil {
+		fmt.Println("Backup reading error:", err)
+		writeValidationError(rw, &models.FieldError{Field: "file", Message: err.Error()})
+		return
+	}
+	result, err := storage.RestoreBackup(accountBackup, dbLogin.Id)
+	var fieldError *models.FieldError
+	if errors.As(err, &fieldError) {
+		writeValidationError(rw, err)
+		return
+	}
+	if err != nil {
+		fmt.Println("Restore error:", err)
+		writeServerError(rw)
+		return
+	}
+	writeJSON(rw, http.StatusOK, result)
+}
@@ -0,0 +1,54 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"spendon/models"
+	"strings"
+	"testing"
+)
+
+// TestRestoreAccountRejectsUploads covers the uploads that are refused before anything is
+// restored.
+func TestRestoreAccountRejectsUploads(t *testing.T) {
+	notMultipart := httptest.NewRequest(http.MethodPost, "/api/restore", strings.NewReader("{}"))
+	notMultipart.Header.Set("Content-Type", "application/json")
+	tests := []struct {
+		name    string
+		request *http.Request
+		limit   int64
+		status  int
+		code    string
+		field   string
+	}{
+		{"not multipart", notMultipart, 0, http.StatusBadRequest, models.InvalidBodyCode, ""},
+		{"too large", importRequest(t, nil, strings.Repeat("x", 4096)), 1024, http.StatusRequestEntityTooLarge, models.BodyTooLargeCode, ""},
+		{"no file", importRequest(t, map[string]string{"format": "zip"}, ""), 0, http.StatusBadRequest, models.ValidationFailedCode, "file"},
+		{"not an archive", importRequest(t, nil, "not a zip"), 0, http.StatusBadRequest, models.ValidationFailedCode, "file"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			if test.limit > 0 {
+				test.request.Body = http.MaxBytesReader(recorder, test.request.Body, test.limit)
+			}
+			server := &Server{}
+			server.restoreAccount(recorder, test.request)
+			if recorder.Code != test.status {
+				t.Errorf("got status %d, want %d", recorder.Code, test.status)
+			}
+			response := models.ErrorResponse{}
+			err := json.Unmarshal(recorder.Body.Bytes(), &response)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if response.Error.Code != test.code {
+				t.Errorf("got code %s, want %s", response.Error.Code, test.code)
+			}
+			if test.field != "" && (len(response.Error.Details) != 1 || response.Error.Details[0].Field != test.field) {
+				t.Errorf("got details %+v, want field %s", response.Error.Details, test.field)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"spendon/models"
+	"spendon/notify"
+	"spendon/storage"
+)
+
+func (server *Server) addBudget(rw http.ResponseWriter, r *http.Request) {
+	dbLogin := LoginFrom(r.Context())
+
+	budget := models.Budget{}
+	if !server.readRequest(rw, r, &budget) {
+		return
+	}
+	err := storage.InsertBudget(&budget, dbLogin.Id)
+	if err != nil {
+		fmt.Println("Insert budget error:", err)
+		writeServerError(rw)
+		return
+	}
+}
+
+func (server *Server) getBudgets(rw http.ResponseWriter, r *http.Request) {
+	dbLogin := LoginFrom(r.Context())
+
+	budgets, err := storage.GetBudgets(dbLogin.Id)
+	if err != nil {
+		fmt.Println("Budgets fetching error:", err)
+		writeServerError(rw)
+		return
+	}
+	writeJSON(rw, http.StatusOK, budgets)
+}
+
+func (server *Server) removeBudget(rw http.ResponseWriter, r *http.Request) {
+	dbLogin := LoginFrom(r.Context())
+
+	removeBudget := models.BudgetRemove{}
+	err := decodeBody(r, &removeBudget)
+	if err != nil {
+		writeBodyError(rw, err)
+		return
+	}
+	err = storage.RemoveBudget(removeBudget.BudgetId, dbLogin.Id)
+	if errors.Is(err, storage.ErrNotFound) {
+		writeError(rw, models.NotFoundCode, "Budget was not found!")
+		return
+	}
+	if err != nil {
+		fmt.Println("Remove budget error:", err)
+		writeServerError(rw)
+		return
+	}
+}
+
+func (server *Server) getBudgetStatus(rw http.ResponseWriter, r *http.Request) {
+	dbLogin := LoginFrom(r.Context())
+
+	budgetStatuses, err := server.service.BudgetStatuses(dbLogin)
+	if err != nil {
+		fmt.Println("Budget status fetching error:", err)
+		writeServerError(rw)
+		return
+	}
+	writeJSON(rw, http.StatusOK, budgetStatuses)
+}
+
+func (server *Server) getAlertDestination(rw http.ResponseWriter, r *http.Request) {
+	dbLogin := LoginFrom(r.Context())
+
+	destination, err := storage.GetAlertDestination(dbLogin.Id)
+	if err != nil {
+		fmt.Println("Alert destination fetching error:", err)
+		writeServerError(rw)
+		return
+	}
+	writeJSON(rw, http.StatusOK, destination)
+}
+
+func (server *Server) saveAlertDestination(rw http.ResponseWriter, r *http.Request) {
+	dbLogin := LoginFrom(r.Context())
+
+	destination := models.AlertDestination{}
+	if !server.readRequest(rw, r, &destination) {
+		return
+	}
+	if destination.WebhookUrl != "" && notify.CheckWebhookUrl(r.Context(), destination.WebhookUrl) != nil {
+		writeError(rw, models.ValidationFailedCode, "Request is not valid!",
+			models.FieldError{Field: "WebhookUrl", Message: "must resolve to a public address"})
+		return
+	}
+	err := storage.SaveAlertDestination(&destination, dbLogin.Id)
+	if err != nil {
+		fmt.Println("Save alert destination error:", err)
+		writeServerError(rw)
+		return
+	}
+	writeJSON(rw, http.StatusOK, destination)
+}
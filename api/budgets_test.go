@@ -0,0 +1,29 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAddBudgetIsValidated(t *testing.T) {
+	server := &Server{}
+	tests := map[string]string{
+		`{"Period": 0}`:                "CategoryId",
+		`{"Period": 7, "Amount": 100}`: "Period",
+		`{"Amount": 0}`:                "Amount",
+		`{"Amount": -50}`:              "Amount",
+	}
+	for body, field := range tests {
+		recorder := httptest.NewRecorder()
+		server.addBudget(recorder, httptest.NewRequest(http.MethodPost, "/api/addbudget", strings.NewReader(body)))
+		if recorder.Code != http.StatusBadRequest {
+			t.Errorf("%s: got status %d, want 400", body, recorder.Code)
+			continue
+		}
+		if !strings.Contains(recorder.Body.String(), `"Field":"`+field+`"`) {
+			t.Errorf("%s: got %s, want field %s", body, recorder.Body.String(), field)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"spendon/models"
+)
+
+func (server *Server) getCategories(rw http.ResponseWriter, r *http.Request) {
+	categories, err := server.service.Categories()
+	if err != nil {
+		fmt.Println("Category fetching error:", err)
+		writeServerError(rw)
+		return
+	}
+	writeJSON(rw, http.StatusOK, categories)
+}
+
+func (server *Server) getFilterSettings(rw http.ResponseWriter, r *http.Request) {
+	filterSettings := models.GetFilterSettings()
+	writeJSON(rw, http.StatusOK, filterSettings)
+}
+
+func (server *Server) getCategoriesStats(rw http.ResponseWriter, r *http.Request) {
+	dbLogin := LoginFrom(r.Context())
+
+	filterBatch := models.FilterBatch{}
+	// Without a body the stats cover every transaction.
+	err := decodeBody(r, &filterBatch)
+	if err != nil && err != io.EOF {
+		writeBodyError(rw, err)
+		return
+	}
+	if !server.validate(rw, filterBatch) {
+		return
+	}
+	categorySummaries, err := server.service.CategoryStats(dbLogin, filterBatch)
+	if err != nil {
+		fmt.Println("Fetching transactions error:", err)
+		writeServerError(rw)
+		return
+	}
+	writeJSON(rw, http.StatusOK, categorySummaries)
+}
@@ -0,0 +1,58 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"spendon/models"
+	"spendon/notify"
+	"spendon/storage"
+)
+
+func (server *Server) getDuplicates(rw http.ResponseWriter, r *http.Request) {
+	dbLogin := LoginFrom(r.Context())
+
+	duplicatesRequest := models.DuplicatesRequest{}
+	err := decodeBody(r, &duplicatesRequest)
+	if err != nil && err != io.EOF {
+		writeBodyError(rw, err)
+		return
+	}
+	if duplicatesRequest.DaysWindow <= 0 {
+		duplicatesRequest.DaysWindow = 3
+	}
+	if duplicatesRequest.MinConfidence <= 0 {
+		duplicatesRequest.MinConfidence = 0.5
+	}
+
+	candidates, err := storage.GetDuplicateCandidates(dbLogin.Id, duplicatesRequest.DaysWindow, duplicatesRequest.MinConfidence)
+	if err != nil {
+		fmt.Println("Duplicates fetching error:", err)
+		writeServerError(rw)
+		return
+	}
+	writeJSON(rw, http.StatusOK, candidates)
+}
+
+func (server *Server) mergeTransactions(rw http.ResponseWriter, r *http.Request) {
+	dbLogin := LoginFrom(r.Context())
+
+	merge := models.TransactionMerge{}
+	if !server.readRequest(rw, r, &merge) {
+		return
+	}
+	mergedTransaction, err := storage.MergeTransactions(&merge, dbLogin.Id, dbLogin.Id)
+	if errors.Is(err, storage.ErrNotFound) {
+		writeError(rw, models.NotFoundCode, "Transaction was not found!")
+		return
+	}
+	if err != nil {
+		fmt.Println("Merge transactions error:", err)
+		writeServerError(rw)
+		return
+	}
+	go notify.CheckBudgets(dbLogin)
+	rw.Header().Set("ETag", transactionETag(mergedTransaction))
+	writeJSON(rw, http.StatusOK, mergedTransaction)
+}
@@ -0,0 +1,30 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"spendon/models"
+	"strings"
+	"testing"
+)
+
+// TestMergeTransactionsRejectsSameIds is answered by validation, before the database is reached.
+func TestMergeTransactionsRejectsSameIds(t *testing.T) {
+	server := &Server{}
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodPost, "/api/mergetransactions", strings.NewReader(`{"KeepId": 7, "RemoveId": 7}`))
+	server.mergeTransactions(recorder, request)
+
+	if recorder.Code != http.StatusBadRequest {
+		t.Fatalf("got status %d, want 400", recorder.Code)
+	}
+	response := models.ErrorResponse{}
+	err := json.Unmarshal(recorder.Body.Bytes(), &response)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.Error.Code != models.ValidationFailedCode || len(response.Error.Details) != 1 || response.Error.Details[0].Field != "RemoveId" {
+		t.Errorf("got error %+v, want validation_failed on RemoveId", response.Error)
+	}
+}
@@ -0,0 +1,81 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"spendon/models"
+	"time"
+)
+
+// keepAliveInterval keeps proxies from closing an event stream that has been quiet for a while.
+const keepAliveInterval = 25 * time.Second
+
+// streamEvents sends the changes of the user as Server-Sent Events. A client reconnecting with
+// Last-Event-ID receives the events it missed, or a reset event when they are no longer kept.
+func (server *Server) streamEvents(rw http.ResponseWriter, r *http.Request) {
+	dbLogin := LoginFrom(r.Context())
+
+	flusher, ok := rw.(http.Flusher)
+	if !ok {
+		fmt.Println("Event stream error: response cannot be flushed")
+		writeServerError(rw)
+		return
+	}
+	lastEventId := r.Header.Get("Last-Event-ID")
+	if lastEventId == "" {
+		lastEventId = r.URL.Query().Get("lastEventId")
+	}
+	subscription, missed, resumed := server.events.Subscribe(dbLogin.Id, lastEventId)
+	defer subscription.Close()
+
+	rw.Header().Set("Content-Type", "text/event-stream")
+	rw.Header().Set("Cache-Control", "no-cache")
+	rw.Header().Set("X-Accel-Buffering", "no")
+	rw.WriteHeader(http.StatusOK)
+
+	if !resumed {
+		missed = append([]models.Event{{Type: models.ResetEvent}}, missed...)
+	}
+	for _, event := range missed {
+		if !writeEvent(rw, event) {
+			return
+		}
+	}
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			_, err := fmt.Fprint(rw, ": ping\n\n")
+			if err != nil {
+				return
+			}
+		case event, ok := <-subscription.Events:
+			if !ok || !writeEvent(rw, event) {
+				return
+			}
+		}
+		flusher.Flush()
+	}
+}
+
+func writeEvent(rw http.ResponseWriter, event models.Event) bool {
+	data, err := json.Marshal(event)
+	if err != nil {
+		fmt.Println("Event encoding error:", err)
+		return true
+	}
+	if event.Id != "" {
+		_, err = fmt.Fprintf(rw, "id: %s\n", event.Id)
+		if err != nil {
+			return false
+		}
+	}
+	_, err = fmt.Fprintf(rw, "event: %s\ndata: %s\n\n", event.Type, data)
+	return err == nil
+}
@@ -0,0 +1,130 @@
+package api
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"spendon/exporter"
+	"spendon/models"
+	"spendon/storage"
+	"strings"
+	"time"
+)
+
+func (server *Server) exportTransactions(rw http.ResponseWriter, r *http.Request) {
+	dbLogin := LoginFrom(r.Context())
+
+	exportRequest := models.ExportRequest{}
+	if !server.readRequest(rw, r, &exportRequest) {
+		return
+	}
+	format, err := exporter.Lookup(exportRequest.Format)
+	if err != nil {
+		writeError(rw, models.ValidationFailedCode, "Request is not valid!", models.FieldError{Field: "Format", Message: err.Error()})
+		return
+	}
+	_, _, err = exportRequest.Filters.Build()
+	if err != nil {
+		writeError(rw, models.ValidationFailedCode, "Request is not valid!", models.FieldError{Field: "Filters", Message: err.Error()})
+		return
+	}
+	categories, err := storage.GetCategories()
+	if err != nil {
+		fmt.Println("Fetching categories error:", err)
+		writeServerError(rw)
+		return
+	}
+	categoryNames := make(map[int32]string, len(categories))
+	for _, category := range categories {
+		categoryNames[category.Id] = category.Name
+	}
+	currency, err := storage.GetUserCurrency(dbLogin.Id)
+	if err != nil {
+		fmt.Println("Fetching currency error:", err)
+		writeServerError(rw)
+		return
+	}
+
+	rw.Header().Set("Content-Type", format.ContentType)
+	rw.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"transactions-%s.%s\"", time.Now().Format("2006-01-02"), format.Extension))
+	writer, err := format.New(rw)
+	if err != nil {
+		fmt.Println("Export error:", err)
+		return
+	}
+	// The status is already sent once rows are streamed, so failures can only be logged and
+	// leave the client with a truncated file.
+	err = storage.StreamTransactions(dbLogin.Id, &exportRequest.Filters, func(transaction *models.Transaction) error {
+		return writer.Write(exporter.NewRow(transaction, categoryNames, currency))
+	})
+	if err != nil {
+		fmt.Println("Export error:", err)
+		return
+	}
+	err = writer.Close()
+	if err != nil {
+		fmt.Println("Export error:", err)
+	}
+}
+
+func (server *Server) exportJournal(rw http.ResponseWriter, r *http.Request) {
+	dbLogin := LoginFrom(r.Context())
+
+	journalRequest := models.JournalRequest{}
+	err := decodeBody(r, &journalRequest)
+	if err != nil {
+		writeBodyError(rw, err)
+		return
+	}
+	filters, err := journalRequest.Filters()
+	if err != nil {
+		writeValidationError(rw, err)
+		return
+	}
+	categories, err := storage.GetCategories()
+	if err != nil {
+		fmt.Println("Fetching categories error:", err)
+		writeServerError(rw)
+		return
+	}
+	categoryNames := make(map[int32]string, len(categories))
+	for _, category := range categories {
+		categoryNames[category.Id] = category.Name
+	}
+	if journalRequest.Naming.Currency == "" {
+		journalRequest.Naming.Currency, err = storage.GetUserCurrency(dbLogin.Id)
+		if err != nil {
+			fmt.Println("Fetching currency error:", err)
+			writeServerError(rw)
+			return
+		}
+	}
+
+	if journalRequest.Dialect == "" {
+		journalRequest.Dialect = models.JournalBeancount
+	}
+	rw.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	rw.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"transactions.%s\"", strings.ToLower(journalRequest.Dialect)))
+	buffer := bufio.NewWriter(rw)
+	writer, err := exporter.NewJournalWriter(buffer, journalRequest.Dialect, journalRequest.Naming, categories)
+	if err != nil {
+		rw.Header().Del("Content-Disposition")
+		writeValidationError(rw, err)
+		return
+	}
+	// Both dialects sort entries by date when loading, so the newest-first stream is kept.
+	err = storage.StreamTransactions(dbLogin.Id, &filters, func(transaction *models.Transaction) error {
+		return writer.Write(exporter.NewRow(transaction, categoryNames, journalRequest.Naming.Currency))
+	})
+	if err != nil {
+		fmt.Println("Export error:", err)
+		return
+	}
+	err = writer.Close()
+	if err == nil {
+		err = buffer.Flush()
+	}
+	if err != nil {
+		fmt.Println("Export error:", err)
+	}
+}
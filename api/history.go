@@ -0,0 +1,52 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"spendon/models"
+	"spendon/notify"
+	"spendon/storage"
+)
+
+func (server *Server) getTransactionHistory(rw http.ResponseWriter, r *http.Request) {
+	dbLogin := LoginFrom(r.Context())
+
+	historyRequest := models.TransactionHistoryRequest{}
+	err := decodeBody(r, &historyRequest)
+	if err != nil {
+		writeBodyError(rw, err)
+		return
+	}
+	history, err := storage.GetTransactionHistory(historyRequest.TransactionId, dbLogin.Id)
+	if err != nil {
+		fmt.Println("Transaction history fetching error:", err)
+		writeServerError(rw)
+		return
+	}
+	writeJSON(rw, http.StatusOK, history)
+}
+
+func (server *Server) restoreTransaction(rw http.ResponseWriter, r *http.Request) {
+	dbLogin := LoginFrom(r.Context())
+
+	restoreRequest := models.TransactionRestore{}
+	err := decodeBody(r, &restoreRequest)
+	if err != nil {
+		writeBodyError(rw, err)
+		return
+	}
+	restoredTransaction, err := storage.RestoreTransactionVersion(restoreRequest.HistoryId, dbLogin.Id, dbLogin.Id)
+	if errors.Is(err, storage.ErrNotFound) {
+		writeError(rw, models.NotFoundCode, "History entry was not found!")
+		return
+	}
+	if err != nil {
+		fmt.Println("Restore transaction error:", err)
+		writeServerError(rw)
+		return
+	}
+	go notify.CheckBudgets(dbLogin)
+	rw.Header().Set("ETag", transactionETag(restoredTransaction))
+	writeJSON(rw, http.StatusOK, restoredTransaction)
+}
@@ -0,0 +1,131 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"spendon/models"
+	"spendon/storage"
+)
+
+// reserveIdempotencyKey reports whether the request should be processed. A repeated request gets
+// the response stored for the first one, an empty key disables the check. The key is bound to the
+// method, path and decoded body of r, so repeating it with another request is refused.
+func (server *Server) reserveIdempotencyKey(rw http.ResponseWriter, r *http.Request, userId int64, key string, request interface{}) bool {
+	if key == "" {
+		return true
+	}
+	requestHash, err := requestFingerprint(r.Method+" "+r.URL.Path, request)
+	if err != nil {
+		fmt.Println("Idempotency key fingerprint error:", err)
+		writeServerError(rw)
+		return false
+	}
+	storedResponse, reserved, err := storage.ReserveIdempotencyKey(userId, key, requestHash, server.settings.IdempotencyKeyTtl())
+	if errors.Is(err, storage.ErrKeyReused) {
+		writeError(rw, models.IdempotencyKeyReusedCode, "The Idempotency-Key was already used for a different request!")
+		return false
+	}
+	if err != nil {
+		fmt.Println("Idempotency key reserve error:", err)
+		writeServerError(rw)
+		return false
+	}
+	if reserved {
+		return true
+	}
+	if storedResponse == nil {
+		writeError(rw, models.ConflictCode, "A request with the same Idempotency-Key is still in progress!")
+		return false
+	}
+	rw.Header().Set("Idempotent-Replayed", "true")
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(storedResponse.StatusCode)
+	_, _ = rw.Write([]byte(storedResponse.Body))
+	return false
+}
+
+// requestFingerprint hashes the scope with the request as it was decoded, so formatting of the
+// body does not matter but every field does.
+func requestFingerprint(scope string, request interface{}) (string, error) {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.New()
+	_, _ = hash.Write([]byte(scope + "\n"))
+	_, _ = hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func releaseIdempotencyKey(userId int64, key string) {
+	if key == "" {
+		return
+	}
+	err := storage.ReleaseIdempotencyKey(userId, key)
+	if err != nil {
+		fmt.Println("Idempotency key release error:", err)
+	}
+}
+
+// writeIdempotentResponse writes the result with status and stores both for the requests repeating the key.
+func writeIdempotentResponse(rw http.ResponseWriter, userId int64, key string, status int, result interface{}) {
+	body, err := json.Marshal(result)
+	if err != nil {
+		fmt.Println("Encoding response error:", err)
+		releaseIdempotencyKey(userId, key)
+		writeServerError(rw)
+		return
+	}
+	if key != "" {
+		err = storage.CompleteIdempotencyKey(userId, key, &models.IdempotentResponse{StatusCode: status, Body: string(body)})
+		if err != nil {
+			fmt.Println("Idempotency key complete error:", err)
+		}
+	}
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(status)
+	_, _ = rw.Write(body)
+}
+
+// insertBulkItem inserts an item of a bulk request once per ClientId. It returns the originally
+// inserted transaction for a repeated ClientId and nil while that item is still being inserted.
+func (server *Server) insertBulkItem(transaction *models.Transaction, userId int64) (*models.Transaction, error) {
+	if transaction.ClientId == "" {
+		err := storage.InsertTransaction(transaction, userId, userId)
+		return transaction, err
+	}
+	itemKey := "item:" + transaction.ClientId
+	requestHash, err := requestFingerprint("item", transaction)
+	if err != nil {
+		return nil, err
+	}
+	storedResponse, reserved, err := storage.ReserveIdempotencyKey(userId, itemKey, requestHash, server.settings.IdempotencyKeyTtl())
+	if err != nil {
+		return nil, err
+	}
+	if !reserved {
+		if storedResponse == nil {
+			return nil, nil
+		}
+		storedTransaction := models.Transaction{}
+		err = json.Unmarshal([]byte(storedResponse.Body), &storedTransaction)
+		return &storedTransaction, err
+	}
+	err = storage.InsertTransaction(transaction, userId, userId)
+	if err != nil {
+		releaseIdempotencyKey(userId, itemKey)
+		return nil, err
+	}
+	body, err := json.Marshal(transaction)
+	if err == nil {
+		err = storage.CompleteIdempotencyKey(userId, itemKey, &models.IdempotentResponse{StatusCode: http.StatusOK, Body: string(body)})
+	}
+	if err != nil {
+		fmt.Println("Idempotency key complete error:", err)
+	}
+	return transaction, nil
+}
@@ -0,0 +1,36 @@
+package api
+
+import (
+	"encoding/json"
+	"spendon/models"
+	"testing"
+)
+
+func TestRequestFingerprint(t *testing.T) {
+	decode := func(body string) models.Transaction {
+		transaction := models.Transaction{}
+		err := json.Unmarshal([]byte(body), &transaction)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return transaction
+	}
+	fingerprint := func(scope string, transaction models.Transaction) string {
+		hash, err := requestFingerprint(scope, transaction)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return hash
+	}
+	first := fingerprint("POST /api/add", decode(`{"Amount": 12.5, "Note": "coffee"}`))
+
+	if got := fingerprint("POST /api/add", decode(`{"Note":"coffee","Amount":12.5}`)); got != first {
+		t.Errorf("reformatted body changed the fingerprint: %s != %s", got, first)
+	}
+	if got := fingerprint("POST /api/add", decode(`{"Amount": 13, "Note": "coffee"}`)); got == first {
+		t.Error("another amount kept the fingerprint")
+	}
+	if got := fingerprint("POST /api/v2/transactions", decode(`{"Amount": 12.5, "Note": "coffee"}`)); got == first {
+		t.Error("another endpoint kept the fingerprint")
+	}
+}
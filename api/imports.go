@@ -0,0 +1,227 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"spendon/importer"
+	"spendon/models"
+	"spendon/notify"
+	"spendon/storage"
+	"spendon/validation"
+	"strconv"
+	"strings"
+)
+
+func (server *Server) previewImport(rw http.ResponseWriter, r *http.Request) {
+	dbLogin := LoginFrom(r.Context())
+
+	preview, ok := readImportRequest(rw, r, dbLogin.Id)
+	if !ok {
+		return
+	}
+	writeJSON(rw, http.StatusOK, preview)
+}
+
+func (server *Server) commitImport(rw http.ResponseWriter, r *http.Request) {
+	dbLogin := LoginFrom(r.Context())
+
+	preview, ok := readImportRequest(rw, r, dbLogin.Id)
+	if !ok {
+		return
+	}
+	importResult := models.ImportResult{Rejected: []models.ImportRow{}}
+	for _, row := range preview.Rows {
+		if row.Error != "" {
+			importResult.Rejected = append(importResult.Rejected, row)
+			continue
+		}
+		_, err := server.insertBulkItem(&row.Transaction, dbLogin.Id)
+		if errors.Is(err, storage.ErrDuplicate) {
+			importResult.Duplicates++
+			continue
+		}
+		if err != nil {
+			fmt.Println("Insert transaction error:", err)
+			importResult.Failed++
+			continue
+		}
+		importResult.Inserted++
+	}
+	go notify.CheckBudgets(dbLogin)
+	writeJSON(rw, http.StatusOK, importResult)
+}
+
+func (server *Server) addImportMapping(rw http.ResponseWriter, r *http.Request) {
+	dbLogin := LoginFrom(r.Context())
+
+	savedMapping := models.SavedImportMapping{}
+	err := decodeBody(r, &savedMapping)
+	if err != nil {
+		writeBodyError(rw, err)
+		return
+	}
+	err = storage.SaveImportMapping(&savedMapping, dbLogin.Id)
+	if err != nil {
+		fmt.Println("Save import mapping error:", err)
+		writeServerError(rw)
+		return
+	}
+}
+
+func (server *Server) getImportMappings(rw http.ResponseWriter, r *http.Request) {
+	dbLogin := LoginFrom(r.Context())
+
+	savedMappings, err := storage.GetImportMappings(dbLogin.Id)
+	if err != nil {
+		fmt.Println("Import mappings fetching error:", err)
+		writeServerError(rw)
+		return
+	}
+	writeJSON(rw, http.StatusOK, savedMappings)
+}
+
+func (server *Server) removeImportMapping(rw http.ResponseWriter, r *http.Request) {
+	dbLogin := LoginFrom(r.Context())
+
+	removeMapping := models.SavedImportMappingRemove{}
+	err := decodeBody(r, &removeMapping)
+	if err != nil {
+		writeBodyError(rw, err)
+		return
+	}
+	err = storage.RemoveImportMapping(removeMapping.MappingId, dbLogin.Id)
+	if errors.Is(err, storage.ErrNotFound) {
+		writeError(rw, models.NotFoundCode, "Import mapping was not found!")
+		return
+	}
+	if err != nil {
+		fmt.Println("Remove import mapping error:", err)
+		writeServerError(rw)
+		return
+	}
+}
+
+// readImportRequest reads a multipart upload with the statement in "file", its "format" (csv by
+// default) and either an inline JSON "mapping" or the id of a saved one in "mappingId". Like
+// readRequest it writes the error response itself: problems with the upload are the client's, a
+// failing lookup is the server's.
+func readImportRequest(rw http.ResponseWriter, r *http.Request, userId int64) (*models.ImportPreview, bool) {
+	err := r.ParseMultipartForm(32 << 20)
+	if err != nil {
+		writeBodyError(rw, err)
+		return nil, false
+	}
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		writeValidationError(rw, &models.FieldError{Field: "file", Message: "statement file is required"})
+		return nil, false
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+	mapping := models.ImportMapping{}
+	if mappingId := r.FormValue("mappingId"); mappingId != "" {
+		id, err := strconv.ParseInt(mappingId, 10, 64)
+		if err != nil {
+			writeValidationError(rw, &models.FieldError{Field: "mappingId", Message: fmt.Sprintf("%q is not an id", mappingId)})
+			return nil, false
+		}
+		savedMapping, err := storage.GetImportMapping(id, userId)
+		if errors.Is(err, storage.ErrNotFound) {
+			writeError(rw, models.NotFoundCode, "Import mapping was not found!")
+			return nil, false
+		}
+		if err != nil {
+			fmt.Println("Import mapping fetching error:", err)
+			writeServerError(rw)
+			return nil, false
+		}
+		mapping = savedMapping.Mapping
+	} else if inlineMapping := r.FormValue("mapping"); inlineMapping != "" {
+		err = json.Unmarshal([]byte(inlineMapping), &mapping)
+		if err != nil {
+			writeValidationError(rw, &models.FieldError{Field: "mapping", Message: "mapping is not valid JSON"})
+			return nil, false
+		}
+	}
+	format := strings.ToLower(r.FormValue("format"))
+	if format == "" {
+		format = "csv"
+	}
+	if !isImportFormat(format) {
+		writeValidationError(rw, &models.FieldError{Field: "format", Message: fmt.Sprintf("%q is not one of %s", format, strings.Join(importFormats(), ", "))})
+		return nil, false
+	}
+	preview, err := importer.Parse(format, file, &mapping)
+	if err != nil {
+		fmt.Println("Import parsing error:", err)
+		writeValidationError(rw, &models.FieldError{Field: "file", Message: err.Error()})
+		return nil, false
+	}
+	categories, err := storage.GetCategories()
+	if err != nil {
+		fmt.Println("Category fetching error:", err)
+		writeServerError(rw)
+		return nil, false
+	}
+	importer.ResolveCategories(preview, categories, mapping.DefaultCategoryId)
+	currency, err := storage.GetUserCurrency(userId)
+	if err != nil {
+		fmt.Println("Fetching currency error:", err)
+		writeServerError(rw)
+		return nil, false
+	}
+	importer.CheckCurrency(preview, currency)
+	err = validateImportRows(newValidator(), preview)
+	if err != nil {
+		fmt.Println("Import validation error:", err)
+		writeServerError(rw)
+		return nil, false
+	}
+	return preview, true
+}
+
+// validateImportRows applies the rules of the transaction API to the rows the parser accepted,
+// so an import cannot store what a client could not. A failing row gets the field errors as its
+// Error and is left out of the insert like any other row with an error.
+func validateImportRows(validator *validation.Validator, preview *models.ImportPreview) error {
+	for idx := range preview.Rows {
+		row := &preview.Rows[idx]
+		if row.Error != "" {
+			continue
+		}
+		fieldErrors, err := validator.Validate(&row.Transaction)
+		if err != nil {
+			return err
+		}
+		if len(fieldErrors) == 0 {
+			continue
+		}
+		messages := make([]string, len(fieldErrors))
+		for fieldIdx := range fieldErrors {
+			messages[fieldIdx] = fieldErrors[fieldIdx].Error()
+		}
+		row.Error = strings.Join(messages, "; ")
+		preview.Valid--
+		preview.Errors++
+	}
+	return nil
+}
+
+func isImportFormat(format string) bool {
+	for _, known := range importer.Formats() {
+		if known == format {
+			return true
+		}
+	}
+	return false
+}
+
+func importFormats() []string {
+	formats := importer.Formats()
+	sort.Strings(formats)
+	return formats
+}
@@ -0,0 +1,114 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"spendon/models"
+	"spendon/validation"
+	"strings"
+	"testing"
+)
+
+func importRequest(t *testing.T, fields map[string]string, file string) *http.Request {
+	t.Helper()
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	for name, value := range fields {
+		err := writer.WriteField(name, value)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if file != "" {
+		part, err := writer.CreateFormFile("file", "statement")
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = part.Write([]byte(file))
+	}
+	err := writer.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := httptest.NewRequest(http.MethodPost, "/api/import/preview", body)
+	r.Header.Set("Content-Type", writer.FormDataContentType())
+	return r
+}
+
+// TestReadImportRequestRejectsUploads covers the failures that are the client's; none of them
+// reaches the database.
+func TestReadImportRequestRejectsUploads(t *testing.T) {
+	notMultipart := httptest.NewRequest(http.MethodPost, "/api/import/preview", strings.NewReader("{}"))
+	notMultipart.Header.Set("Content-Type", "application/json")
+	tests := []struct {
+		name    string
+		request *http.Request
+		code    string
+		field   string
+	}{
+		{"not multipart", notMultipart, models.InvalidBodyCode, ""},
+		{"no file", importRequest(t, map[string]string{"format": "csv"}, ""), models.ValidationFailedCode, "file"},
+		{"bad mapping id", importRequest(t, map[string]string{"mappingId": "first"}, "a,b"), models.ValidationFailedCode, "mappingId"},
+		{"bad inline mapping", importRequest(t, map[string]string{"mapping": "{"}, "a,b"), models.ValidationFailedCode, "mapping"},
+		{"unknown format", importRequest(t, map[string]string{"format": "pdf"}, "a,b"), models.ValidationFailedCode, "format"},
+		{"unreadable statement", importRequest(t, map[string]string{"format": "monobank"}, "not json"), models.ValidationFailedCode, "file"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			_, ok := readImportRequest(recorder, test.request, 1)
+			if ok {
+				t.Fatal("the upload was accepted")
+			}
+			if recorder.Code != http.StatusBadRequest {
+				t.Errorf("got status %d, want 400", recorder.Code)
+			}
+			response := models.ErrorResponse{}
+			err := json.Unmarshal(recorder.Body.Bytes(), &response)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if response.Error.Code != test.code {
+				t.Errorf("got code %s, want %s", response.Error.Code, test.code)
+			}
+			if test.field != "" && (len(response.Error.Details) != 1 || response.Error.Details[0].Field != test.field) {
+				t.Errorf("got details %+v, want field %s", response.Error.Details, test.field)
+			}
+		})
+	}
+}
+
+func TestValidateImportRows(t *testing.T) {
+	validator := validation.New().Register("category", func(value reflect.Value, _ string) (string, error) {
+		if value.Int() != 3 {
+			return "category does not exist", nil
+		}
+		return "", nil
+	})
+	preview := &models.ImportPreview{}
+	preview.Add(models.ImportRow{Line: 1, Transaction: models.Transaction{Amount: 12.5, SpentAt: "2024-03-01", CategoryId: 3}})
+	preview.Add(models.ImportRow{Line: 2, Transaction: models.Transaction{Amount: 4, SpentAt: "2024-03-02", CategoryId: 9}})
+	preview.Add(models.ImportRow{Line: 3, Transaction: models.Transaction{Amount: 2e9, SpentAt: "2024-03-03", CategoryId: 3, Note: strings.Repeat("n", 501)}})
+	preview.Add(models.ImportRow{Line: 4, Error: "amount is not a number"})
+
+	err := validateImportRows(validator, preview)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"", "CategoryId: category does not exist", "Amount: ", "amount is not a number"}
+	for idx, row := range preview.Rows {
+		if (want[idx] == "") != (row.Error == "") || !strings.HasPrefix(row.Error, want[idx]) {
+			t.Errorf("row %d got error %q, want %q", row.Line, row.Error, want[idx])
+		}
+	}
+	if !strings.Contains(preview.Rows[2].Error, "; Note: ") {
+		t.Errorf("row 3 does not report every field: %q", preview.Rows[2].Error)
+	}
+	if preview.Valid != 1 || preview.Errors != 3 {
+		t.Errorf("got %d valid and %d errors, want 1 and 3", preview.Valid, preview.Errors)
+	}
+}
@@ -0,0 +1,150 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"spendon/models"
+	"time"
+)
+
+type contextKey int
+
+const (
+	loginKey contextKey = iota
+	requestIdKey
+	pathParamsKey
+)
+
+const requestIdHeader = "X-Request-Id"
+
+// RequestId takes the request id from the X-Request-Id header or generates one, and sends it back.
+func RequestId(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		requestId := r.Header.Get(requestIdHeader)
+		if requestId == "" || len(requestId) > 128 {
+			idBytes := make([]byte, 8)
+			_, _ = rand.Read(idBytes)
+			requestId = hex.EncodeToString(idBytes)
+		}
+		rw.Header().Set(requestIdHeader, requestId)
+		next.ServeHTTP(rw, r.WithContext(context.WithValue(r.Context(), requestIdKey, requestId)))
+	})
+}
+
+func RequestIdFrom(ctx context.Context) string {
+	requestId, _ := ctx.Value(requestIdKey).(string)
+	return requestId
+}
+
+// statusRecorder remembers the status written by the handler for the log.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (recorder *statusRecorder) WriteHeader(status int) {
+	if recorder.status == 0 {
+		recorder.status = status
+	}
+	recorder.ResponseWriter.WriteHeader(status)
+}
+
+func (recorder *statusRecorder) Write(body []byte) (int, error) {
+	if recorder.status == 0 {
+		recorder.status = http.StatusOK
+	}
+	return recorder.ResponseWriter.Write(body)
+}
+
+// Flush keeps streaming responses working behind the recorder.
+func (recorder *statusRecorder) Flush() {
+	if flusher, ok := recorder.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func Logging(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		started := time.Now()
+		recorder := &statusRecorder{ResponseWriter: rw}
+		next.ServeHTTP(recorder, r)
+		if recorder.status == 0 {
+			recorder.status = http.StatusOK
+		}
+		fmt.Println(RequestIdFrom(r.Context()), r.Method, r.URL.Path, recorder.status, time.Since(started))
+	})
+}
+
+// Recover turns a panicking handler into a server error instead of a dropped connection.
+func Recover(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				if recovered == http.ErrAbortHandler {
+					panic(recovered)
+				}
+				fmt.Println("Panic in request", RequestIdFrom(r.Context()), ":", recovered)
+				fmt.Println(string(debug.Stack()))
+				writeServerError(rw)
+			}
+		}()
+		next.ServeHTTP(rw, r)
+	})
+}
+
+func CORS(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.Header().Add("Access-Control-Allow-Origin", "*")
+		rw.Header().Add("Access-Control-Allow-Methods", "*")
+		rw.Header().Add("Access-Control-Allow-Headers", "*")
+		rw.Header().Add("Access-Control-Expose-Headers", "ETag, Idempotent-Replayed, Content-Disposition, X-Request-Id, Location")
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// LimitBody fails reading the request body after limit bytes.
+func LimitBody(limit int64) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			r.Body = http.MaxBytesReader(rw, r.Body, limit)
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
+
+// authenticate validates the Token header and puts the user into the request context.
+func (server *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		dbLogin, err := server.validateLoginToken(r.Header.Get("Token"))
+		if err != nil {
+			fmt.Println(err)
+			writeError(rw, models.UnauthorizedCode, "Authorize failure!")
+			return
+		}
+		next.ServeHTTP(rw, r.WithContext(context.WithValue(r.Context(), loginKey, dbLogin)))
+	})
+}
+
+// authenticateStream also takes the token from the query, as EventSource in browsers cannot send
+// headers. It is kept to the event stream: anywhere else a token in the URL would end up in logs
+// and browser history for no reason.
+func (server *Server) authenticateStream(next http.Handler) http.Handler {
+	authenticated := server.authenticate(next)
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if token := r.URL.Query().Get("token"); token != "" && r.Header.Get("Token") == "" {
+			r = r.Clone(r.Context())
+			r.Header.Set("Token", token)
+		}
+		authenticated.ServeHTTP(rw, r)
+	})
+}
+
+// LoginFrom returns the user put into the context by the authentication middleware.
+func LoginFrom(ctx context.Context) *models.DbLogin {
+	dbLogin, _ := ctx.Value(loginKey).(*models.DbLogin)
+	return dbLogin
+}
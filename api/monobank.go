@@ -0,0 +1,117 @@
+package api
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"spendon/importer"
+	"spendon/models"
+	"spendon/notify"
+	"spendon/storage"
+	"strconv"
+)
+
+func (server *Server) registerMonobankWebhook(rw http.ResponseWriter, r *http.Request) {
+	dbLogin := LoginFrom(r.Context())
+
+	secretBytes := make([]byte, 24)
+	_, err := rand.Read(secretBytes)
+	if err != nil {
+		fmt.Println("Secret generation error:", err)
+		writeServerError(rw)
+		return
+	}
+	secret := hex.EncodeToString(secretBytes)
+	err = storage.SaveMonobankWebhookSecret(dbLogin.Id, secret)
+	if err != nil {
+		fmt.Println("Save Monobank webhook error:", err)
+		writeServerError(rw)
+		return
+	}
+	scheme := r.Header.Get("X-Forwarded-Proto")
+	if scheme == "" {
+		scheme = "https"
+	}
+	registration := models.MonobankWebhookRegistration{
+		WebhookUrl: fmt.Sprintf("%s://%s/api/monobank/webhook/%d?secret=%s", scheme, r.Host, dbLogin.Id, secret),
+	}
+	writeJSON(rw, http.StatusOK, registration)
+}
+
+// monobankWebhookUser finds the user of the webhook URL. Monobank can only be given a URL, so the
+// secret stays in its query, which the request log leaves out. The user is looked up by id and the
+// secret compared in constant time, so neither the query nor the comparison reveals it.
+func monobankWebhookUser(rw http.ResponseWriter, r *http.Request) (*models.DbLogin, bool) {
+	userId, err := strconv.ParseInt(PathParam(r, "id"), 10, 64)
+	if err != nil {
+		writeError(rw, models.NotFoundCode, "Webhook was not found!")
+		return nil, false
+	}
+	dbLogin, secret, err := storage.GetMonobankWebhook(userId)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		fmt.Println("Monobank webhook user fetching error:", err)
+		writeServerError(rw)
+		return nil, false
+	}
+	given := r.URL.Query().Get("secret")
+	if err != nil || given == "" || subtle.ConstantTimeCompare([]byte(given), []byte(secret)) != 1 {
+		writeError(rw, models.NotFoundCode, "Webhook was not found!")
+		return nil, false
+	}
+	return dbLogin, true
+}
+
+// checkMonobankWebhook answers the GET request Monobank sends to the URL when the webhook is set;
+// Monobank only accepts a URL that answers it with 200.
+func (server *Server) checkMonobankWebhook(rw http.ResponseWriter, r *http.Request) {
+	monobankWebhookUser(rw, r)
+}
+
+func (server *Server) receiveMonobankWebhook(rw http.ResponseWriter, r *http.Request) {
+	dbLogin, ok := monobankWebhookUser(rw, r)
+	if !ok {
+		return
+	}
+
+	preview, err := importer.ParseMonobankWebhook(r.Body)
+	if err != nil {
+		// Monobank retries failed deliveries, so a payload we cannot read is only logged.
+		fmt.Println("Monobank webhook parsing error:", err)
+		return
+	}
+	categories, err := storage.GetCategories()
+	if err != nil {
+		fmt.Println("Category fetching error:", err)
+		writeServerError(rw)
+		return
+	}
+	var defaultCategoryId int32
+	for _, category := range categories {
+		if category.Name == "Others" {
+			defaultCategoryId = category.Id
+		}
+	}
+	importer.ResolveCategories(preview, categories, defaultCategoryId)
+	err = validateImportRows(newValidator(), preview)
+	if err != nil {
+		fmt.Println("Monobank webhook validation error:", err)
+		writeServerError(rw)
+		return
+	}
+	for _, row := range preview.Rows {
+		if row.Error != "" {
+			fmt.Println("Monobank webhook item error:", row.Error)
+			continue
+		}
+		err = storage.InsertTransaction(&row.Transaction, dbLogin.Id, dbLogin.Id)
+		if err != nil && !errors.Is(err, storage.ErrDuplicate) {
+			fmt.Println("Insert transaction error:", err)
+			writeServerError(rw)
+			return
+		}
+	}
+	go notify.CheckBudgets(dbLogin)
+}
@@ -0,0 +1,392 @@
+package api
+
+import (
+	_ "embed"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"spendon/models"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed docs.html
+var docsPage []byte
+
+// operation documents a route. Every route is registered together with one, and the schemas are
+// read from the models themselves, so the specification follows the code it describes.
+type operation struct {
+	summary  string
+	request  interface{}
+	response interface{}
+	status   int
+	produces string
+	form     []parameter
+	query    []parameter
+	headers  []parameter
+}
+
+type parameter struct {
+	name        string
+	description string
+}
+
+func describe(summary string) operation {
+	return operation{summary: summary, status: http.StatusOK}
+}
+
+// accepts sets the model of the JSON request body.
+func (op operation) accepts(model interface{}) operation {
+	op.request = model
+	return op
+}
+
+// returns sets the model of the JSON response body.
+func (op operation) returns(model interface{}) operation {
+	op.response = model
+	return op
+}
+
+func (op operation) withStatus(status int) operation {
+	op.status = status
+	return op
+}
+
+// producing marks a response that is a file of contentType instead of JSON.
+func (op operation) producing(contentType string) operation {
+	op.produces = contentType
+	return op
+}
+
+// withForm adds a field of a multipart request body. A field named "file" is the uploaded file.
+func (op operation) withForm(name, description string) operation {
+	op.form = append(op.form, parameter{name, description})
+	return op
+}
+
+func (op operation) withQuery(name, description string) operation {
+	op.query = append(op.query, parameter{name, description})
+	return op
+}
+
+func (op operation) withHeader(name, description string) operation {
+	op.headers = append(op.headers, parameter{name, description})
+	return op
+}
+
+// specification collects the OpenAPI 3 document while the routes are registered.
+type specification struct {
+	paths   map[string]map[string]interface{}
+	schemas map[string]interface{}
+	// operations keeps what each route was documented with, keyed like Router.Routes.
+	operations map[string]operation
+}
+
+func newSpecification() *specification {
+	return &specification{
+		paths:      make(map[string]map[string]interface{}),
+		schemas:    make(map[string]interface{}),
+		operations: make(map[string]operation),
+	}
+}
+
+func (spec *specification) add(method, path string, op operation, authenticated bool) {
+	spec.operations[method+" "+path] = op
+	item := map[string]interface{}{
+		"summary":     op.summary,
+		"operationId": operationId(method, path),
+		"tags":        []string{tagOf(path)},
+	}
+	parameters := make([]interface{}, 0)
+	for _, segment := range strings.Split(path, "/") {
+		if strings.HasPrefix(segment, "{") {
+			parameters = append(parameters, map[string]interface{}{
+				"name":     strings.Trim(segment, "{}"),
+				"in":       "path",
+				"required": true,
+				"schema":   map[string]interface{}{"type": "integer", "format": "int64"},
+			})
+		}
+	}
+	for _, query := range op.query {
+		parameters = append(parameters, map[string]interface{}{
+			"name":        query.name,
+			"in":          "query",
+			"description": query.description,
+			"schema":      map[string]interface{}{"type": "string"},
+		})
+	}
+	for _, header := range op.headers {
+		parameters = append(parameters, map[string]interface{}{
+			"name":        header.name,
+			"in":          "header",
+			"description": header.description,
+			"schema":      map[string]interface{}{"type": "string"},
+		})
+	}
+	if len(parameters) > 0 {
+		item["parameters"] = parameters
+	}
+
+	if op.request != nil {
+		item["requestBody"] = map[string]interface{}{
+			"required": true,
+			"content": map[string]interface{}{
+				"application/json": map[string]interface{}{"schema": spec.schemaOf(reflect.TypeOf(op.request))},
+			},
+		}
+	} else if len(op.form) > 0 {
+		properties := make(map[string]interface{}, len(op.form))
+		for _, field := range op.form {
+			property := map[string]interface{}{"type": "string", "description": field.description}
+			if field.name == "file" {
+				property["format"] = "binary"
+			}
+			properties[field.name] = property
+		}
+		item["requestBody"] = map[string]interface{}{
+			"required": true,
+			"content": map[string]interface{}{
+				"multipart/form-data": map[string]interface{}{
+					"schema": map[string]interface{}{"type": "object", "properties": properties, "required": []string{"file"}},
+				},
+			},
+		}
+	}
+
+	success := map[string]interface{}{"description": http.StatusText(op.status)}
+	if op.produces != "" {
+		success["content"] = map[string]interface{}{
+			op.produces: map[string]interface{}{"schema": map[string]interface{}{"type": "string", "format": "binary"}},
+		}
+	} else if op.response != nil {
+		success["content"] = map[string]interface{}{
+			"application/json": map[string]interface{}{"schema": spec.schemaOf(reflect.TypeOf(op.response))},
+		}
+	}
+	errorResponse := map[string]interface{}{"$ref": "#/components/responses/Error"}
+	responses := map[string]interface{}{
+		strconv.Itoa(op.status): success,
+		"default":               errorResponse,
+	}
+	if op.request != nil || len(op.form) > 0 || len(op.query) > 0 {
+		responses["400"] = errorResponse
+	}
+	if authenticated {
+		responses["401"] = errorResponse
+		item["security"] = []interface{}{map[string]interface{}{"token": []string{}}}
+	}
+	if strings.Contains(path, "{") {
+		responses["404"] = errorResponse
+	}
+	item["responses"] = responses
+
+	methods, ok := spec.paths[path]
+	if !ok {
+		methods = make(map[string]interface{})
+		spec.paths[path] = methods
+	}
+	methods[strings.ToLower(method)] = item
+}
+
+// document returns the specification ready to be encoded.
+func (spec *specification) document() map[string]interface{} {
+	spec.schemaOf(reflect.TypeOf(models.ErrorResponse{}))
+	codes := make([]string, 0, len(errorStatuses))
+	for code := range errorStatuses {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	codeLines := make([]string, 0, len(codes))
+	for _, code := range codes {
+		codeLines = append(codeLines, fmt.Sprintf("- `%s`: %d", code, errorStatuses[code]))
+	}
+	apiError := spec.schemas["ApiError"].(map[string]interface{})
+	apiError["properties"].(map[string]interface{})["Code"] = map[string]interface{}{"type": "string", "enum": codes}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":   "SpendOn API",
+			"version": "2.0.0",
+			"description": "Every failed request answers with an ErrorResponse. Its Code is stable and " +
+				"decides the HTTP status:\n\n" + strings.Join(codeLines, "\n"),
+		},
+		"paths": spec.paths,
+		"components": map[string]interface{}{
+			"schemas": spec.schemas,
+			"responses": map[string]interface{}{
+				"Error": map[string]interface{}{
+					"description": "Error",
+					"content": map[string]interface{}{
+						"application/json": map[string]interface{}{"schema": map[string]interface{}{"$ref": "#/components/schemas/ErrorResponse"}},
+					},
+				},
+			},
+			"securitySchemes": map[string]interface{}{
+				"token": map[string]interface{}{"type": "apiKey", "in": "header", "name": "Token"},
+			},
+		},
+	}
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schemaOf registers named structs as components and refers to them, other types are inlined.
+func (spec *specification) schemaOf(modelType reflect.Type) map[string]interface{} {
+	for modelType.Kind() == reflect.Ptr {
+		modelType = modelType.Elem()
+	}
+	if modelType == timeType {
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+	switch modelType.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return map[string]interface{}{"type": "integer", "format": "int32"}
+	case reflect.Int64, reflect.Uint, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "format": "int64"}
+	case reflect.Float32:
+		return map[string]interface{}{"type": "number", "format": "float"}
+	case reflect.Float64:
+		return map[string]interface{}{"type": "number", "format": "double"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if modelType.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "format": "byte"}
+		}
+		return map[string]interface{}{"type": "array", "items": spec.schemaOf(modelType.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": spec.schemaOf(modelType.Elem())}
+	case reflect.Struct:
+		if modelType.Name() == "" {
+			return spec.structSchema(modelType)
+		}
+		if _, ok := spec.schemas[modelType.Name()]; !ok {
+			// Reserved first, so a model referring to itself does not recurse forever.
+			spec.schemas[modelType.Name()] = map[string]interface{}{}
+			spec.schemas[modelType.Name()] = spec.structSchema(modelType)
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + modelType.Name()}
+	}
+	return map[string]interface{}{}
+}
+
+// structSchema follows encoding/json: embedded structs are flattened and json tags rename or hide fields.
+func (spec *specification) structSchema(modelType reflect.Type) map[string]interface{} {
+	properties := make(map[string]interface{})
+	required := make([]string, 0)
+	var collect func(structType reflect.Type)
+	collect = func(structType reflect.Type) {
+		for idx := 0; idx < structType.NumField(); idx++ {
+			field := structType.Field(idx)
+			jsonTag := field.Tag.Get("json")
+			if jsonTag == "-" {
+				continue
+			}
+			name := strings.Split(jsonTag, ",")[0]
+			if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+				collect(field.Type)
+				continue
+			}
+			if field.PkgPath != "" {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			schema := spec.schemaOf(field.Type)
+			if applyRules(schema, field.Tag.Get("validate"), field.Type) {
+				required = append(required, name)
+			}
+			properties[name] = schema
+		}
+	}
+	collect(modelType)
+	schema := map[string]interface{}{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// applyRules describes the validation rules of a field in its schema and reports whether it is required.
+func applyRules(schema map[string]interface{}, tag string, fieldType reflect.Type) bool {
+	if tag == "" {
+		return false
+	}
+	isRequired := false
+	for _, declaration := range strings.Split(tag, ",") {
+		name, param := declaration, ""
+		if idx := strings.Index(declaration, "="); idx >= 0 {
+			name, param = declaration[:idx], declaration[idx+1:]
+		}
+		limit, _ := strconv.ParseFloat(param, 64)
+		switch name {
+		case "required":
+			isRequired = true
+		case "min", "max":
+			schema[limitKeyword(name, fieldType.Kind())] = limit
+		case "oneof":
+			options := make([]interface{}, 0)
+			for _, option := range strings.Fields(param) {
+				if number, err := strconv.Atoi(option); err == nil && fieldType.Kind() != reflect.String {
+					options = append(options, number)
+				} else {
+					options = append(options, option)
+				}
+			}
+			schema["enum"] = options
+		case "timestamp":
+			schema["description"] = "Date and time like 2006-01-02T15:04:05"
+		case "password":
+			schema["minLength"] = 8
+			schema["description"] = "At least 8 characters with a letter and a digit"
+		case "category":
+			schema["description"] = "Id of an existing category"
+		}
+	}
+	return isRequired
+}
+
+func limitKeyword(rule string, kind reflect.Kind) string {
+	switch kind {
+	case reflect.String:
+		return rule + "Length"
+	case reflect.Slice, reflect.Array:
+		return rule + "Items"
+	}
+	if rule == "min" {
+		return "minimum"
+	}
+	return "maximum"
+}
+
+// operationId turns "GET /api/v2/transactions/{id}" into "getV2TransactionsId".
+func operationId(method, path string) string {
+	words := []string{strings.ToLower(method)}
+	for _, segment := range strings.Split(path, "/") {
+		segment = strings.Trim(segment, "{}")
+		if segment == "" || segment == "api" {
+			continue
+		}
+		words = append(words, strings.ToUpper(segment[:1])+segment[1:])
+	}
+	return strings.Join(words, "")
+}
+
+func tagOf(path string) string {
+	if strings.HasPrefix(path, v2Prefix) {
+		return "v2"
+	}
+	return "v1"
+}
+
+func serveDocs(rw http.ResponseWriter, r *http.Request) {
+	rw.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = rw.Write(docsPage)
+}
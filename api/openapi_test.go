@@ -0,0 +1,175 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"spendon/events"
+	"spendon/settings"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// openApiDocument holds the parts of the served document the drift tests compare.
+type openApiDocument struct {
+	Paths      map[string]map[string]openApiOperation
+	Components struct {
+		Schemas map[string]openApiSchema
+	}
+}
+
+type openApiOperation struct {
+	RequestBody *struct {
+		Content map[string]struct{ Schema openApiSchema }
+	}
+	Responses map[string]struct {
+		Content map[string]struct{ Schema openApiSchema }
+	}
+}
+
+type openApiSchema struct {
+	Ref        string `json:"$ref"`
+	Type       string
+	Items      *openApiSchema
+	Properties map[string]json.RawMessage
+}
+
+func servedDocument(t *testing.T, handler http.Handler, path string) openApiDocument {
+	t.Helper()
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("GET %s answered %d", path, recorder.Code)
+	}
+	document := openApiDocument{}
+	err := json.Unmarshal(recorder.Body.Bytes(), &document)
+	if err != nil {
+		t.Fatalf("GET %s is not a JSON document: %v", path, err)
+	}
+	return document
+}
+
+func (document *openApiDocument) routes() []string {
+	routes := make([]string, 0)
+	for path, methods := range document.Paths {
+		for method := range methods {
+			routes = append(routes, strings.ToUpper(method)+" "+path)
+		}
+	}
+	sort.Strings(routes)
+	return routes
+}
+
+// resolve follows $ref and array items down to the schema of the model's struct.
+func (document *openApiDocument) resolve(t *testing.T, schema openApiSchema) openApiSchema {
+	t.Helper()
+	for {
+		switch {
+		case schema.Ref != "":
+			name := strings.TrimPrefix(schema.Ref, "#/components/schemas/")
+			resolved, ok := document.Components.Schemas[name]
+			if !ok {
+				t.Fatalf("schema %s is referenced but not defined", schema.Ref)
+			}
+			schema = resolved
+		case schema.Type == "array" && schema.Items != nil:
+			schema = *schema.Items
+		default:
+			return schema
+		}
+	}
+}
+
+func newTestServer() *Server {
+	return NewServer(&settings.Settings{}, events.NewBus(10))
+}
+
+func TestSpecificationListsEveryRoute(t *testing.T) {
+	router, spec := newTestServer().routes()
+	routes := router.Routes()
+	if len(routes) == 0 {
+		t.Fatal("the router has no routes")
+	}
+	for _, path := range []string{"/api/openapi.json", v2Prefix + "/openapi.json"} {
+		document := servedDocument(t, router, path)
+		if documented := document.routes(); !reflect.DeepEqual(documented, routes) {
+			t.Errorf("%s lists\n%v\nbut the router has\n%v", path, documented, routes)
+		}
+	}
+	for _, route := range routes {
+		if _, ok := spec.operations[route]; !ok {
+			t.Errorf("route %s was registered without an operation", route)
+		}
+	}
+}
+
+// TestSpecificationMatchesModels checks the documented bodies against the models the handlers
+// encode and decode: every field a model writes is documented, and every documented property is
+// a field of the model.
+func TestSpecificationMatchesModels(t *testing.T) {
+	router, spec := newTestServer().routes()
+	document := servedDocument(t, router, "/api/openapi.json")
+	for _, route := range router.Routes() {
+		op := spec.operations[route]
+		fields := strings.SplitN(route, " ", 2)
+		documented := document.Paths[fields[1]][strings.ToLower(fields[0])]
+		if op.request != nil {
+			if documented.RequestBody == nil {
+				t.Errorf("%s: request body %T is not documented", route, op.request)
+			} else {
+				checkModel(t, route+" request", op.request, document.resolve(t, documented.RequestBody.Content["application/json"].Schema))
+			}
+		}
+		response, ok := documented.Responses[strconv.Itoa(op.status)]
+		if !ok {
+			t.Errorf("%s: status %d is not documented", route, op.status)
+			continue
+		}
+		if op.produces != "" {
+			if _, ok := response.Content[op.produces]; !ok {
+				t.Errorf("%s: content type %s is not documented", route, op.produces)
+			}
+		} else if op.response != nil {
+			content, ok := response.Content["application/json"]
+			if !ok {
+				t.Errorf("%s: response %T is not documented", route, op.response)
+				continue
+			}
+			checkModel(t, route+" response", op.response, document.resolve(t, content.Schema))
+		}
+	}
+}
+
+func checkModel(t *testing.T, name string, model interface{}, schema openApiSchema) {
+	t.Helper()
+	modelType := reflect.TypeOf(model)
+	for modelType.Kind() == reflect.Slice || modelType.Kind() == reflect.Ptr {
+		modelType = modelType.Elem()
+	}
+	if modelType.Kind() != reflect.Struct {
+		return
+	}
+	encoded, err := json.Marshal(reflect.New(modelType).Interface())
+	if err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+	written := map[string]json.RawMessage{}
+	_ = json.Unmarshal(encoded, &written)
+	for field := range written {
+		if _, ok := schema.Properties[field]; !ok {
+			t.Errorf("%s: field %s of %s is not documented", name, field, modelType.Name())
+		}
+	}
+	for property := range schema.Properties {
+		decoder := json.NewDecoder(bytes.NewReader([]byte(`{"` + property + `":null}`)))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(reflect.New(modelType).Interface())
+		if err != nil {
+			t.Errorf("%s: documented property %s is not a field of %s: %v", name, property, modelType.Name(), err)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"spendon/models"
+	"spendon/storage"
+	"time"
+)
+
+func (server *Server) addRecurring(rw http.ResponseWriter, r *http.Request) {
+	dbLogin := LoginFrom(r.Context())
+
+	rule := models.RecurringRule{}
+	if !server.readRequest(rw, r, &rule) {
+		return
+	}
+	err := storage.InsertRecurringRule(&rule, dbLogin.Id)
+	if err != nil {
+		fmt.Println("Insert recurring rule error:", err)
+		writeServerError(rw)
+		return
+	}
+}
+
+func (server *Server) getRecurring(rw http.ResponseWriter, r *http.Request) {
+	dbLogin := LoginFrom(r.Context())
+
+	rules, err := storage.GetRecurringRules(dbLogin.Id)
+	if err != nil {
+		fmt.Println("Recurring rules fetching error:", err)
+		writeServerError(rw)
+		return
+	}
+	writeJSON(rw, http.StatusOK, rules)
+}
+
+func (server *Server) removeRecurring(rw http.ResponseWriter, r *http.Request) {
+	dbLogin := LoginFrom(r.Context())
+
+	removeRule := models.RecurringRuleRemove{}
+	err := decodeBody(r, &removeRule)
+	if err != nil {
+		writeBodyError(rw, err)
+		return
+	}
+	err = storage.RemoveRecurringRule(removeRule.RuleId, dbLogin.Id)
+	if errors.Is(err, storage.ErrNotFound) {
+		writeError(rw, models.NotFoundCode, "Recurring rule was not found!")
+		return
+	}
+	if err != nil {
+		fmt.Println("Remove recurring rule error:", err)
+		writeServerError(rw)
+		return
+	}
+}
+
+func (server *Server) previewRecurring(rw http.ResponseWriter, r *http.Request) {
+	dbLogin := LoginFrom(r.Context())
+
+	previewRequest := models.RecurringPreviewRequest{}
+	err := decodeBody(r, &previewRequest)
+	if err != nil {
+		writeBodyError(rw, err)
+		return
+	}
+	rule, err := storage.GetRecurringRule(previewRequest.RuleId, dbLogin.Id)
+	if errors.Is(err, storage.ErrNotFound) {
+		writeError(rw, models.NotFoundCode, "Recurring rule was not found!")
+		return
+	}
+	if err != nil {
+		fmt.Println("Recurring rule fetching error:", err)
+		writeServerError(rw)
+		return
+	}
+	exceptions, err := storage.GetRecurringExceptions(rule.Id)
+	if err != nil {
+		fmt.Println("Recurring exceptions fetching error:", err)
+		writeServerError(rw)
+		return
+	}
+	count := previewRequest.Count
+	if count <= 0 || count > 100 {
+		count = 10
+	}
+	now := time.Now().UTC()
+	occurrences := make([]models.RecurringOccurrence, 0, count)
+	for _, date := range rule.Occurrences(now, now.AddDate(10, 0, 0)) {
+		if len(occurrences) == count {
+			break
+		}
+		transaction, skipped := rule.TransactionFor(date, exceptions.Find(date))
+		occurrences = append(occurrences, models.RecurringOccurrence{
+			Date:        date,
+			Skipped:     skipped,
+			Transaction: transaction,
+		})
+	}
+	writeJSON(rw, http.StatusOK, occurrences)
+}
+
+func (server *Server) updateOccurrence(rw http.ResponseWriter, r *http.Request) {
+	dbLogin := LoginFrom(r.Context())
+
+	exception := models.RecurringException{}
+	if !server.readRequest(rw, r, &exception) {
+		return
+	}
+	err := storage.UpsertRecurringException(&exception, dbLogin.Id)
+	if errors.Is(err, storage.ErrNotFound) {
+		writeError(rw, models.NotFoundCode, "Recurring rule was not found!")
+		return
+	}
+	if err != nil {
+		fmt.Println("Update occurrence error:", err)
+		writeServerError(rw)
+		return
+	}
+}
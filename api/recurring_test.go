@@ -0,0 +1,51 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"spendon/models"
+	"strings"
+	"testing"
+)
+
+// TestRecurringRequestsAreValidated covers requests refused before the database is reached.
+func TestRecurringRequestsAreValidated(t *testing.T) {
+	server := &Server{}
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		body    string
+		field   string
+	}{
+		{"no start date", server.addRecurring, `{"Amount": 10}`, "StartDate"},
+		{"zero start date", server.addRecurring, `{"Amount": 10, "Frequency": 1, "StartDate": "0001-01-01T00:00:00Z"}`, "StartDate"},
+		{"unknown frequency", server.addRecurring, `{"Amount": 10, "Frequency": 5, "StartDate": "2024-01-01T00:00:00Z"}`, "Frequency"},
+		{"negative interval", server.addRecurring, `{"Amount": 10, "Interval": -1, "StartDate": "2024-01-01T00:00:00Z"}`, "Interval"},
+		{"negative count", server.addRecurring, `{"Amount": 10, "Count": -2, "StartDate": "2024-01-01T00:00:00Z"}`, "Count"},
+		{"day of month", server.addRecurring, `{"Amount": 10, "DayOfMonth": 32, "StartDate": "2024-01-01T00:00:00Z"}`, "DayOfMonth"},
+		{"end before start", server.addRecurring, `{"Amount": 10, "StartDate": "2024-01-01T00:00:00Z", "EndDate": "2023-12-01T00:00:00Z"}`, "EndDate"},
+		{"exception without rule", server.updateOccurrence, `{"OccurrenceDate": "2024-01-01T00:00:00Z"}`, "RuleId"},
+		{"exception amount", server.updateOccurrence, `{"RuleId": 1, "OccurrenceDate": "2024-01-01T00:00:00Z", "Amount": 2000000000}`, "Amount"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			test.handler(recorder, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(test.body)))
+			if recorder.Code != http.StatusBadRequest {
+				t.Fatalf("got status %d, want 400", recorder.Code)
+			}
+			response := models.ErrorResponse{}
+			err := json.Unmarshal(recorder.Body.Bytes(), &response)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, detail := range response.Error.Details {
+				if detail.Field == test.field {
+					return
+				}
+			}
+			t.Errorf("got details %+v, want field %s", response.Error.Details, test.field)
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"spendon/models"
+	"spendon/storage"
+	"spendon/validation"
+)
+
+// decodeBody reads the JSON body into value and refuses fields value does not have, so a typo
+// in a field name is reported instead of silently dropped.
+func decodeBody(r *http.Request, value interface{}) error {
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(value)
+}
+
+// readRequest decodes and validates the body. It writes the error response itself and reports
+// whether the handler may go on.
+func (server *Server) readRequest(rw http.ResponseWriter, r *http.Request, value interface{}) bool {
+	err := decodeBody(r, value)
+	if err != nil {
+		writeBodyError(rw, err)
+		return false
+	}
+	return server.validate(rw, value)
+}
+
+func (server *Server) validate(rw http.ResponseWriter, value interface{}) bool {
+	fieldErrors, err := newValidator().Validate(value)
+	if err != nil {
+		fmt.Println("Validation error:", err)
+		writeServerError(rw)
+		return false
+	}
+	if len(fieldErrors) > 0 {
+		writeError(rw, models.ValidationFailedCode, "Request is not valid!", fieldErrors...)
+		return false
+	}
+	return true
+}
+
+// newValidator is created per request, so categories are read once for a whole bulk insert
+// and never outlive it.
+func newValidator() *validation.Validator {
+	var categoryIds map[int64]bool
+	return validation.New().Register("category", func(value reflect.Value, _ string) (string, error) {
+		if categoryIds == nil {
+			categories, err := storage.GetCategories()
+			if err != nil {
+				return "", err
+			}
+			categoryIds = make(map[int64]bool, len(categories))
+			for _, category := range categories {
+				categoryIds[int64(category.Id)] = true
+			}
+		}
+		if !categoryIds[value.Int()] {
+			return "category does not exist", nil
+		}
+		return "", nil
+	})
+}
@@ -0,0 +1,90 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"spendon/models"
+	"strings"
+)
+
+const serverErrorMessage = "An error occurred on the server! This message is already delivered to developer ;)"
+
+var errorStatuses = map[string]int{
+	models.ValidationFailedCode:     http.StatusBadRequest,
+	models.InvalidBodyCode:          http.StatusBadRequest,
+	models.BodyTooLargeCode:         http.StatusRequestEntityTooLarge,
+	models.UnauthorizedCode:         http.StatusUnauthorized,
+	models.ForbiddenCode:            http.StatusForbidden,
+	models.NotFoundCode:             http.StatusNotFound,
+	models.MethodNotAllowedCode:     http.StatusMethodNotAllowed,
+	models.ConflictCode:             http.StatusConflict,
+	models.VersionConflictCode:      http.StatusConflict,
+	models.IdempotencyKeyReusedCode: http.StatusUnprocessableEntity,
+	models.PreconditionRequiredCode: http.StatusPreconditionRequired,
+	models.InternalErrorCode:        http.StatusInternalServerError,
+}
+
+// writeError writes the error envelope with the status that belongs to code. The request id
+// is the one the RequestId middleware put into the response headers.
+func writeError(rw http.ResponseWriter, code, message string, details ...models.FieldError) {
+	writeApiError(rw, models.ApiError{Code: code, Message: message, Details: details})
+}
+
+func writeApiError(rw http.ResponseWriter, apiError models.ApiError) {
+	status, ok := errorStatuses[apiError.Code]
+	if !ok {
+		status = http.StatusInternalServerError
+	}
+	apiError.RequestId = rw.Header().Get(requestIdHeader)
+	body, err := json.Marshal(models.ErrorResponse{Error: apiError})
+	if err != nil {
+		fmt.Println("Encoding error response error:", err)
+		rw.WriteHeader(status)
+		return
+	}
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(status)
+	_, _ = rw.Write(append(body, '\n'))
+}
+
+func writeServerError(rw http.ResponseWriter) {
+	writeError(rw, models.InternalErrorCode, serverErrorMessage)
+}
+
+// writeBodyError reports a request body that could not be read, telling an oversized body
+// apart from a malformed one.
+func writeBodyError(rw http.ResponseWriter, err error) {
+	fmt.Println("Decode body error:", err)
+	// Go 1.17 has no typed error for http.MaxBytesReader, only this message.
+	if strings.Contains(err.Error(), "request body too large") {
+		writeError(rw, models.BodyTooLargeCode, "Request body is too large!")
+		return
+	}
+	writeError(rw, models.InvalidBodyCode, err.Error())
+}
+
+// writeValidationError reports a request that was read but rejected, with the field details
+// when err carries them.
+func writeValidationError(rw http.ResponseWriter, err error) {
+	var fieldError *models.FieldError
+	if errors.As(err, &fieldError) {
+		writeError(rw, models.ValidationFailedCode, "Request is not valid!", *fieldError)
+		return
+	}
+	writeError(rw, models.ValidationFailedCode, err.Error())
+}
+
+// writeJSON encodes value before anything is written, so an encoding failure still gets a proper error status.
+func writeJSON(rw http.ResponseWriter, status int, value interface{}) {
+	body, err := json.Marshal(value)
+	if err != nil {
+		fmt.Println("Encoding response error:", err)
+		writeServerError(rw)
+		return
+	}
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(status)
+	_, _ = rw.Write(append(body, '\n'))
+}
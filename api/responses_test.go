@@ -0,0 +1,40 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"spendon/models"
+	"testing"
+)
+
+func TestWriteJSON(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	writeJSON(recorder, http.StatusAccepted, models.AccountDeletion{Id: 3})
+
+	if recorder.Code != http.StatusAccepted {
+		t.Errorf("got status %d, want 202", recorder.Code)
+	}
+	if contentType := recorder.Header().Get("Content-Type"); contentType != "application/json" {
+		t.Errorf("got Content-Type %q", contentType)
+	}
+	deletion := models.AccountDeletion{}
+	err := json.Unmarshal(recorder.Body.Bytes(), &deletion)
+	if err != nil || deletion.Id != 3 {
+		t.Errorf("got body %q", recorder.Body.String())
+	}
+}
+
+func TestWriteJSONReportsEncodingFailure(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	writeJSON(recorder, http.StatusCreated, make(chan int))
+
+	if recorder.Code != http.StatusInternalServerError {
+		t.Fatalf("got status %d, want 500", recorder.Code)
+	}
+	response := models.ErrorResponse{}
+	err := json.Unmarshal(recorder.Body.Bytes(), &response)
+	if err != nil || response.Error.Code != models.InternalErrorCode {
+		t.Errorf("got body %q", recorder.Body.String())
+	}
+}
@@ -0,0 +1,147 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"spendon/models"
+	"strings"
+)
+
+// Middleware wraps a handler with behaviour shared by many routes.
+type Middleware func(next http.Handler) http.Handler
+
+// Router dispatches requests by path and method. Middlewares given to NewRouter run for every
+// request, including the ones without a route; those given to Handle run for that route only.
+//
+// A path segment written as {name} matches any single segment, which the handler reads with
+// PathParam. Paths without parameters are matched first.
+type Router struct {
+	routes   map[string]map[string]http.Handler
+	patterns []*pattern
+	handler  http.Handler
+}
+
+type pattern struct {
+	segments []string
+	methods  map[string]http.Handler
+}
+
+func NewRouter(middlewares ...Middleware) *Router {
+	router := &Router{routes: make(map[string]map[string]http.Handler)}
+	router.handler = chain(http.HandlerFunc(router.dispatch), middlewares)
+	return router
+}
+
+func (router *Router) Handle(method, path string, handler http.HandlerFunc, middlewares ...Middleware) {
+	router.methodsFor(path)[method] = chain(handler, middlewares)
+}
+
+func (router *Router) methodsFor(path string) map[string]http.Handler {
+	if !strings.Contains(path, "{") {
+		methods, ok := router.routes[path]
+		if !ok {
+			methods = make(map[string]http.Handler)
+			router.routes[path] = methods
+		}
+		return methods
+	}
+	segments := strings.Split(path, "/")
+	for _, existing := range router.patterns {
+		if strings.Join(existing.segments, "/") == path {
+			return existing.methods
+		}
+	}
+	added := &pattern{segments: segments, methods: make(map[string]http.Handler)}
+	router.patterns = append(router.patterns, added)
+	return added.methods
+}
+
+// Routes lists the registered routes as "METHOD /path", sorted.
+func (router *Router) Routes() []string {
+	routes := make([]string, 0, len(router.routes)+len(router.patterns))
+	add := func(path string, methods map[string]http.Handler) {
+		for method := range methods {
+			routes = append(routes, method+" "+path)
+		}
+	}
+	for path, methods := range router.routes {
+		add(path, methods)
+	}
+	for _, pattern := range router.patterns {
+		add(strings.Join(pattern.segments, "/"), pattern.methods)
+	}
+	sort.Strings(routes)
+	return routes
+}
+
+// match returns the methods of the route for path with the values of its parameters.
+func (router *Router) match(path string) (map[string]http.Handler, map[string]string) {
+	if methods, ok := router.routes[path]; ok {
+		return methods, nil
+	}
+	segments := strings.Split(path, "/")
+	for _, candidate := range router.patterns {
+		if len(candidate.segments) != len(segments) {
+			continue
+		}
+		params := make(map[string]string)
+		for idx, segment := range candidate.segments {
+			if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") && segments[idx] != "" {
+				params[segment[1:len(segment)-1]] = segments[idx]
+			} else if segment != segments[idx] {
+				params = nil
+				break
+			}
+		}
+		if params != nil {
+			return candidate.methods, params
+		}
+	}
+	return nil, nil
+}
+
+// PathParam returns the value of the {name} segment of the matched route.
+func PathParam(r *http.Request, name string) string {
+	params, _ := r.Context().Value(pathParamsKey).(map[string]string)
+	return params[name]
+}
+
+func (router *Router) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	router.handler.ServeHTTP(rw, r)
+}
+
+func (router *Router) dispatch(rw http.ResponseWriter, r *http.Request) {
+	methods, params := router.match(r.URL.Path)
+	if methods == nil {
+		writeError(rw, models.NotFoundCode, "There is no such endpoint!")
+		return
+	}
+	// Preflight requests only need the CORS headers.
+	if r.Method == http.MethodOptions {
+		return
+	}
+	handler, ok := methods[r.Method]
+	if !ok {
+		allowed := make([]string, 0, len(methods))
+		for method := range methods {
+			allowed = append(allowed, method)
+		}
+		sort.Strings(allowed)
+		rw.Header().Set("Allow", strings.Join(allowed, ", "))
+		writeError(rw, models.MethodNotAllowedCode, "Please, use "+strings.Join(allowed, " or ")+" method!")
+		return
+	}
+	if params != nil {
+		r = r.WithContext(context.WithValue(r.Context(), pathParamsKey, params))
+	}
+	handler.ServeHTTP(rw, r)
+}
+
+// chain makes the first middleware the outermost one.
+func chain(handler http.Handler, middlewares []Middleware) http.Handler {
+	for idx := len(middlewares) - 1; idx >= 0; idx-- {
+		handler = middlewares[idx](handler)
+	}
+	return handler
+}
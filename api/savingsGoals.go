@@ -0,0 +1,82 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"spendon/models"
+	"spendon/storage"
+	"time"
+)
+
+func (server *Server) addGoal(rw http.ResponseWriter, r *http.Request) {
+	dbLogin := LoginFrom(r.Context())
+
+	goal := models.SavingsGoal{}
+	err := decodeBody(r, &goal)
+	if err != nil {
+		writeBodyError(rw, err)
+		return
+	}
+	err = storage.InsertSavingsGoal(&goal, dbLogin.Id)
+	if err != nil {
+		fmt.Println("Insert savings goal error:", err)
+		writeServerError(rw)
+		return
+	}
+}
+
+func (server *Server) getGoals(rw http.ResponseWriter, r *http.Request) {
+	dbLogin := LoginFrom(r.Context())
+
+	goals, err := storage.GetSavingsGoals(dbLogin.Id)
+	if err != nil {
+		fmt.Println("Savings goals fetching error:", err)
+		writeServerError(rw)
+		return
+	}
+	writeJSON(rw, http.StatusOK, goals)
+}
+
+func (server *Server) removeGoal(rw http.ResponseWriter, r *http.Request) {
+	dbLogin := LoginFrom(r.Context())
+
+	goalRequest := models.SavingsGoalRequest{}
+	err := decodeBody(r, &goalRequest)
+	if err != nil {
+		writeBodyError(rw, err)
+		return
+	}
+	err = storage.RemoveSavingsGoal(goalRequest.GoalId, dbLogin.Id)
+	if errors.Is(err, storage.ErrNotFound) {
+		writeError(rw, models.NotFoundCode, "Savings goal was not found!")
+		return
+	}
+	if err != nil {
+		fmt.Println("Remove savings goal error:", err)
+		writeServerError(rw)
+		return
+	}
+}
+
+func (server *Server) getGoalProgress(rw http.ResponseWriter, r *http.Request) {
+	dbLogin := LoginFrom(r.Context())
+
+	goalRequest := models.SavingsGoalRequest{}
+	err := decodeBody(r, &goalRequest)
+	if err != nil {
+		writeBodyError(rw, err)
+		return
+	}
+	progress, err := storage.GetSavingsGoalProgress(goalRequest.GoalId, dbLogin.Id, time.Now().UTC())
+	if errors.Is(err, storage.ErrNotFound) {
+		writeError(rw, models.NotFoundCode, "Savings goal was not found!")
+		return
+	}
+	if err != nil {
+		fmt.Println("Savings goal progress fetching error:", err)
+		writeServerError(rw)
+		return
+	}
+	writeJSON(rw, http.StatusOK, progress)
+}
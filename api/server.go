@@ -0,0 +1,229 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"spendon/events"
+	"spendon/models"
+	"spendon/service"
+	"spendon/settings"
+)
+
+const (
+	defaultBodyLimit = 4 << 20
+	// uploadBodyLimit is for statement imports and backup archives.
+	uploadBodyLimit = 64 << 20
+)
+
+// Server holds what the handlers share. Handler returns the router with every route of the API.
+type Server struct {
+	settings *settings.Settings
+	service  *service.Service
+	events   *events.Bus
+}
+
+func NewServer(loadedSettings *settings.Settings, bus *events.Bus) *Server {
+	return &Server{settings: loadedSettings, service: service.New(loadedSettings), events: bus}
+}
+
+func (server *Server) Handler() http.Handler {
+	router, _ := server.routes()
+	return router
+}
+
+// routes registers every route together with its documentation.
+func (server *Server) routes() (*Router, *specification) {
+	router := NewRouter(RequestId, Logging, Recover, CORS)
+	spec := newSpecification()
+	public := func(method, path string, handler http.HandlerFunc, op operation) {
+		router.Handle(method, path, handler, LimitBody(defaultBodyLimit))
+		spec.add(method, path, op, false)
+	}
+	authenticated := func(method, path string, handler http.HandlerFunc, op operation) {
+		router.Handle(method, path, handler, LimitBody(defaultBodyLimit), server.authenticate)
+		spec.add(method, path, op, true)
+	}
+	upload := func(method, path string, handler http.HandlerFunc, op operation) {
+		router.Handle(method, path, handler, LimitBody(uploadBodyLimit), server.authenticate)
+		spec.add(method, path, op, true)
+	}
+	stream := func(method, path string, handler http.HandlerFunc, op operation) {
+		router.Handle(method, path, handler, LimitBody(defaultBodyLimit), server.authenticateStream)
+		spec.add(method, path, op, true)
+	}
+	const (
+		idempotencyKey  = "Repeating a request with the same key returns the first response instead of inserting again; using the key for a different request answers 422"
+		ifMatch         = "ETag of the transaction being updated, replaces the Version field"
+		importFile      = "Bank statement"
+		importFormat    = "Statement format, csv by default"
+		importMapping   = "CSV column mapping as JSON"
+		importMappingId = "Id of a saved CSV column mapping"
+	)
+
+	public(http.MethodPost, "/api/login", server.login,
+		describe("Sign in").accepts(models.Login{}).returns(models.LoginResult{}))
+	public(http.MethodPost, "/api/register", server.register,
+		describe("Create a user and sign in").accepts(models.RegisterModel{}).returns(models.LoginResult{}))
+	authenticated(http.MethodGet, "/api/checkauth", server.checkAuth,
+		describe("Check the token"))
+	authenticated(http.MethodPost, "/api/checkauth", server.checkAuth,
+		describe("Check the token"))
+
+	public(http.MethodGet, "/api/getcategories", server.getCategories,
+		describe("List categories").returns(models.Categories{}))
+	public(http.MethodGet, "/api/getfiltersettings", server.getFilterSettings,
+		describe("List filter properties and operators").returns(models.FilterSettings{}))
+	authenticated(http.MethodPost, "/api/getcategoriesstats", server.getCategoriesStats,
+		describe("Sum transactions by category").accepts(models.FilterBatch{}).returns(models.CategoriesSummary{}))
+
+	authenticated(http.MethodPost, "/api/add", server.addTransaction,
+		describe("Add a transaction").accepts(models.Transaction{}).returns(models.Transaction{}).
+			withHeader("Idempotency-Key", idempotencyKey))
+	authenticated(http.MethodPost, "/api/bulkadd", server.bulkAddTransactions,
+		describe("Add many transactions").accepts(models.BulkTransactions{}).returns(models.BulkTransactions{}).
+			withHeader("Idempotency-Key", idempotencyKey))
+	authenticated(http.MethodPut, "/api/updatetransaction", server.updateTransaction,
+		describe("Update a transaction").accepts(models.Transaction{}).returns(models.Transaction{}).
+			withHeader("If-Match", ifMatch))
+	authenticated(http.MethodDelete, "/api/removetransaction", server.removeTransaction,
+		describe("Move a transaction to the trash").accepts(models.TransactionRemove{}))
+	authenticated(http.MethodPost, "/api/fetchtransactions", server.fetchTransactions,
+		describe("List transactions page by page").accepts(models.FilteredRequest{}).returns(models.PagedTransactions{}))
+
+	authenticated(http.MethodPost, "/api/addrecurring", server.addRecurring,
+		describe("Add a recurring rule").accepts(models.RecurringRule{}))
+	authenticated(http.MethodGet, "/api/getrecurring", server.getRecurring,
+		describe("List recurring rules").returns(models.RecurringRules{}))
+	authenticated(http.MethodDelete, "/api/removerecurring", server.removeRecurring,
+		describe("Remove a recurring rule").accepts(models.RecurringRuleRemove{}))
+	authenticated(http.MethodPost, "/api/previewrecurring", server.previewRecurring,
+		describe("Preview the next occurrences of a rule").accepts(models.RecurringPreviewRequest{}).returns([]models.RecurringOccurrence{}))
+	authenticated(http.MethodPost, "/api/updateoccurrence", server.updateOccurrence,
+		describe("Skip or change one occurrence of a rule").accepts(models.RecurringException{}))
+
+	authenticated(http.MethodPost, "/api/addbudget", server.addBudget,
+		describe("Add a budget").accepts(models.Budget{}))
+	authenticated(http.MethodGet, "/api/getbudgets", server.getBudgets,
+		describe("List budgets").returns(models.Budgets{}))
+	authenticated(http.MethodDelete, "/api/removebudget", server.removeBudget,
+		describe("Remove a budget").accepts(models.BudgetRemove{}))
+	authenticated(http.MethodGet, "/api/getbudgetstatus", server.getBudgetStatus,
+		describe("Show spending of the current budget periods").returns(models.BudgetStatuses{}))
+
+	authenticated(http.MethodPost, "/api/addgoal", server.addGoal,
+		describe("Add a savings goal").accepts(models.SavingsGoal{}))
+	authenticated(http.MethodGet, "/api/getgoals", server.getGoals,
+		describe("List savings goals").returns(models.SavingsGoals{}))
+	authenticated(http.MethodDelete, "/api/removegoal", server.removeGoal,
+		describe("Remove a savings goal").accepts(models.SavingsGoalRequest{}))
+	authenticated(http.MethodPost, "/api/getgoalprogress", server.getGoalProgress,
+		describe("Show progress of a savings goal").accepts(models.SavingsGoalRequest{}).returns(models.SavingsGoalProgress{}))
+
+	authenticated(http.MethodPost, "/api/transactionhistory", server.getTransactionHistory,
+		describe("List changes of a transaction").accepts(models.TransactionHistoryRequest{}).returns(models.TransactionHistory{}))
+	authenticated(http.MethodPost, "/api/restoretransaction", server.restoreTransaction,
+		describe("Bring a transaction back to a recorded state").accepts(models.TransactionRestore{}).returns(models.Transaction{}))
+
+	authenticated(http.MethodGet, "/api/gettrash", server.getTrash,
+		describe("List deleted transactions").returns(models.TrashedTransactions{}))
+	authenticated(http.MethodPost, "/api/restorefromtrash", server.restoreFromTrash,
+		describe("Restore deleted transactions").accepts(models.TrashRequest{}))
+	authenticated(http.MethodDelete, "/api/purgetrash", server.purgeTrash,
+		describe("Delete transactions from the trash for good").accepts(models.TrashRequest{}))
+
+	authenticated(http.MethodPost, "/api/getduplicates", server.getDuplicates,
+		describe("Find probable duplicate transactions").accepts(models.DuplicatesRequest{}).returns(models.DuplicateCandidates{}))
+	authenticated(http.MethodPost, "/api/mergetransactions", server.mergeTransactions,
+		describe("Merge duplicate transactions into one").accepts(models.TransactionMerge{}).returns(models.Transaction{}))
+
+	upload(http.MethodPost, "/api/importpreview", server.previewImport,
+		describe("Parse a statement without saving it").returns(models.ImportPreview{}).
+			withForm("file", importFile).withForm("format", importFormat).withForm("mapping", importMapping).withForm("mappingId", importMappingId))
+	upload(http.MethodPost, "/api/importcommit", server.commitImport,
+		describe("Import the transactions of a statement").returns(models.ImportResult{}).
+			withForm("file", importFile).withForm("format", importFormat).withForm("mapping", importMapping).withForm("mappingId", importMappingId))
+	authenticated(http.MethodPost, "/api/addimportmapping", server.addImportMapping,
+		describe("Save a CSV column mapping").accepts(models.SavedImportMapping{}))
+	authenticated(http.MethodGet, "/api/getimportmappings", server.getImportMappings,
+		describe("List saved CSV column mappings").returns(models.SavedImportMappings{}))
+	authenticated(http.MethodDelete, "/api/removeimportmapping", server.removeImportMapping,
+		describe("Remove a saved CSV column mapping").accepts(models.SavedImportMappingRemove{}))
+
+	authenticated(http.MethodPost, "/api/monobank/registerwebhook", server.registerMonobankWebhook,
+		describe("Create the Monobank webhook URL of the user").returns(models.MonobankWebhookRegistration{}))
+	public(http.MethodGet, "/api/monobank/webhook/{id}", server.checkMonobankWebhook,
+		describe("Answer the Monobank URL check").withQuery("secret", "Secret of the webhook URL"))
+	public(http.MethodPost, "/api/monobank/webhook/{id}", server.receiveMonobankWebhook,
+		describe("Receive a Monobank statement item").withQuery("secret", "Secret of the webhook URL"))
+
+	authenticated(http.MethodPost, "/api/export", server.exportTransactions,
+		describe("Export transactions as CSV, NDJSON or XLSX").accepts(models.ExportRequest{}).producing("application/octet-stream"))
+	authenticated(http.MethodPost, "/api/exportjournal", server.exportJournal,
+		describe("Export transactions as a beancount or ledger journal").accepts(models.JournalRequest{}).producing("text/plain"))
+	authenticated(http.MethodPost, "/api/backup", server.backupAccount,
+		describe("Download a backup archive of the account").producing("application/zip"))
+	upload(http.MethodPost, "/api/restore", server.restoreAccount,
+		describe("Restore a backup archive into the account").returns(models.RestoreResult{}).
+			withForm("file", "Backup archive"))
+
+	authenticated(http.MethodPost, "/api/deleteaccount", server.deleteAccount,
+		describe("Schedule the account for deletion").accepts(models.AccountDeletionRequest{}).returns(models.AccountDeletion{}).
+			withStatus(http.StatusAccepted))
+	authenticated(http.MethodGet, "/api/getaccountdeletion", server.getAccountDeletion,
+		describe("Show the scheduled account deletion").returns(models.AccountDeletion{}))
+	authenticated(http.MethodPost, "/api/canceldeleteaccount", server.cancelAccountDeletion,
+		describe("Cancel the scheduled account deletion"))
+	authenticated(http.MethodGet, "/api/admin/accountdeletions", server.getAccountDeletions,
+		describe("List account deletions, for administrators").returns(models.AccountDeletions{}))
+
+	// v2 addresses resources by path, so the v1 routes above stay as they are for older clients.
+	authenticated(http.MethodGet, v2Prefix+"/me", server.getProfileV2,
+		describe("Show the signed in user").returns(models.Profile{}))
+	public(http.MethodGet, v2Prefix+"/categories", server.getCategories,
+		describe("List categories").returns(models.Categories{}))
+	authenticated(http.MethodGet, v2Prefix+"/transactions", server.listTransactionsV2,
+		withTransactionFilters(describe("List transactions page by page").returns(models.PagedTransactions{})).
+			withQuery("page", "Page number starting from 0").withQuery("pageSize", "Transactions per page, 50 by default"))
+	authenticated(http.MethodPost, v2Prefix+"/transactions", server.createTransactionV2,
+		describe("Add a transaction").accepts(models.Transaction{}).returns(models.Transaction{}).withStatus(http.StatusCreated).
+			withHeader("Idempotency-Key", idempotencyKey))
+	authenticated(http.MethodGet, v2Prefix+"/transactions/{id}", server.getTransactionV2,
+		describe("Show a transaction").returns(models.Transaction{}))
+	authenticated(http.MethodPut, v2Prefix+"/transactions/{id}", server.updateTransactionV2,
+		describe("Update a transaction").accepts(models.Transaction{}).returns(models.Transaction{}).
+			withHeader("If-Match", ifMatch))
+	authenticated(http.MethodDelete, v2Prefix+"/transactions/{id}", server.removeTransactionV2,
+		describe("Move a transaction to the trash").withStatus(http.StatusNoContent))
+	authenticated(http.MethodGet, v2Prefix+"/stats/categories", server.getCategoryStatsV2,
+		withTransactionFilters(describe("Sum transactions by category").returns(models.CategoriesSummary{})))
+	authenticated(http.MethodGet, v2Prefix+"/stats/budgets", server.getBudgetStatus,
+		describe("Show spending of the current budget periods").returns(models.BudgetStatuses{}))
+	authenticated(http.MethodGet, v2Prefix+"/me/alerts", server.getAlertDestination,
+		describe("Show where budget alerts are sent").returns(models.AlertDestination{}))
+	authenticated(http.MethodPut, v2Prefix+"/me/alerts", server.saveAlertDestination,
+		describe("Choose where budget alerts are sent, empty fields turn them off").accepts(models.AlertDestination{}).returns(models.AlertDestination{}))
+	stream(http.MethodGet, v2Prefix+"/events", server.streamEvents,
+		describe("Stream changes of transactions and budgets as Server-Sent Events").producing("text/event-stream").
+			withHeader("Last-Event-ID", "Id of the last event received, to resume after it").
+			withQuery("lastEventId", "Same as the Last-Event-ID header").
+			withQuery("token", "Token, for clients that cannot send the Token header"))
+
+	public(http.MethodGet, "/api/docs", serveDocs,
+		describe("Read this documentation").producing("text/html"))
+	// The document is encoded below, once every route has added its operation.
+	var document []byte
+	serveDocument := func(rw http.ResponseWriter, r *http.Request) {
+		rw.Header().Set("Content-Type", "application/json")
+		_, _ = rw.Write(document)
+	}
+	public(http.MethodGet, "/api/openapi.json", serveDocument,
+		describe("Download this OpenAPI document").returns(map[string]interface{}{}))
+	public(http.MethodGet, v2Prefix+"/openapi.json", serveDocument,
+		describe("Download this OpenAPI document").returns(map[string]interface{}{}))
+	document, err := json.Marshal(spec.document())
+	if err != nil {
+		panic(fmt.Sprintf("OpenAPI document encoding error: %v", err))
+	}
+	return router, spec
+}
@@ -0,0 +1,160 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"spendon/models"
+	"spendon/notify"
+	"spendon/storage"
+	"strconv"
+	"strings"
+)
+
+func (server *Server) addTransaction(rw http.ResponseWriter, r *http.Request) {
+	dbLogin := LoginFrom(r.Context())
+	transaction := models.Transaction{}
+
+	if !server.readRequest(rw, r, &transaction) {
+		return
+	}
+
+	idempotencyKey := r.Header.Get("Idempotency-Key")
+	if !server.reserveIdempotencyKey(rw, r, dbLogin.Id, idempotencyKey, transaction) {
+		return
+	}
+	err := server.service.CreateTransaction(dbLogin, &transaction)
+	if err != nil {
+		fmt.Println("Insert transaction error:", err)
+		releaseIdempotencyKey(dbLogin.Id, idempotencyKey)
+		writeServerError(rw)
+		return
+	}
+	writeIdempotentResponse(rw, dbLogin.Id, idempotencyKey, http.StatusOK, transaction)
+}
+
+func (server *Server) bulkAddTransactions(rw http.ResponseWriter, r *http.Request) {
+	dbLogin := LoginFrom(r.Context())
+	transactions := make(models.BulkTransactions, 0)
+
+	if !server.readRequest(rw, r, &transactions) {
+		return
+	}
+
+	idempotencyKey := r.Header.Get("Idempotency-Key")
+	if !server.reserveIdempotencyKey(rw, r, dbLogin.Id, idempotencyKey, transactions) {
+		return
+	}
+	insertedTransactions := make(models.BulkTransactions, 0, len(transactions))
+	for _, transaction := range transactions {
+		insertedTransaction, err := server.insertBulkItem(&transaction, dbLogin.Id)
+		if err != nil {
+			fmt.Println("Insert transaction error:", err)
+			continue
+		}
+		if insertedTransaction != nil {
+			insertedTransactions = append(insertedTransactions, *insertedTransaction)
+		}
+	}
+	go notify.CheckBudgets(dbLogin)
+	writeIdempotentResponse(rw, dbLogin.Id, idempotencyKey, http.StatusOK, insertedTransactions)
+}
+
+func (server *Server) updateTransaction(rw http.ResponseWriter, r *http.Request) {
+	dbLogin := LoginFrom(r.Context())
+	transaction := models.Transaction{}
+
+	if !server.readRequest(rw, r, &transaction) {
+		return
+	}
+	server.writeUpdatedTransaction(rw, r, dbLogin, &transaction)
+}
+
+// writeUpdatedTransaction applies the update when the version from If-Match or the body is the
+// stored one and writes the result, or the stored transaction on a conflict.
+func (server *Server) writeUpdatedTransaction(rw http.ResponseWriter, r *http.Request, dbLogin *models.DbLogin, transaction *models.Transaction) {
+	if version, ok := parseIfMatch(r.Header.Get("If-Match")); ok {
+		transaction.Version = version
+	}
+	if transaction.Version == 0 {
+		writeError(rw, models.PreconditionRequiredCode, "Please, send the expected transaction version in If-Match header or Version field!")
+		return
+	}
+
+	resultTransaction, err := server.service.UpdateTransaction(dbLogin, transaction)
+
+	var conflictError *storage.ConflictError
+	if errors.As(err, &conflictError) {
+		fmt.Println("Update transaction conflict:", err)
+		rw.Header().Set("ETag", transactionETag(conflictError.Current))
+		writeApiError(rw, models.ApiError{
+			Code:    models.VersionConflictCode,
+			Message: "Transaction was changed by another request!",
+			Current: *conflictError.Current,
+		})
+	} else if errors.Is(err, storage.ErrNotFound) {
+		writeError(rw, models.NotFoundCode, "Transaction was not found!")
+	} else if err != nil {
+		fmt.Println("Update transaction error:", err)
+		writeServerError(rw)
+	} else {
+		rw.Header().Set("ETag", transactionETag(resultTransaction))
+		writeJSON(rw, http.StatusOK, *resultTransaction)
+	}
+}
+
+func (server *Server) removeTransaction(rw http.ResponseWriter, r *http.Request) {
+	dbLogin := LoginFrom(r.Context())
+
+	removeTransaction := models.TransactionRemove{}
+	err := decodeBody(r, &removeTransaction)
+	if err != nil {
+		writeBodyError(rw, err)
+		return
+	}
+	server.removeTransactionById(rw, dbLogin, removeTransaction.TransactionId)
+}
+
+// removeTransactionById writes the error response itself and reports whether the transaction was removed.
+func (server *Server) removeTransactionById(rw http.ResponseWriter, dbLogin *models.DbLogin, id int64) bool {
+	err := server.service.RemoveTransaction(dbLogin, id)
+	if errors.Is(err, storage.ErrNotFound) {
+		writeError(rw, models.NotFoundCode, "Transaction was not found!")
+		return false
+	}
+	if err != nil {
+		fmt.Println("Remove transaction error:", err)
+		writeServerError(rw)
+		return false
+	}
+	return true
+}
+
+func (server *Server) fetchTransactions(rw http.ResponseWriter, r *http.Request) {
+	dbLogin := LoginFrom(r.Context())
+	filteredRequest := models.FilteredRequest{}
+	if !server.readRequest(rw, r, &filteredRequest) {
+		return
+	}
+	transactions, err := server.service.ListTransactions(dbLogin, &filteredRequest)
+	if err != nil {
+		fmt.Println("Fetching transactions error:", err)
+		writeServerError(rw)
+		return
+	}
+	writeJSON(rw, http.StatusOK, transactions)
+}
+
+func transactionETag(transaction *models.Transaction) string {
+	return fmt.Sprintf("\"%d\"", transaction.Version)
+}
+
+// parseIfMatch reads the transaction version from an If-Match header produced by transactionETag.
+func parseIfMatch(header string) (int32, bool) {
+	value := strings.Trim(strings.TrimPrefix(strings.TrimSpace(header), "W/"), "\"")
+	version, err := strconv.ParseInt(value, 10, 32)
+	if err != nil {
+		return 0, false
+	}
+	return int32(version), true
+}
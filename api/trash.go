@@ -0,0 +1,58 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"spendon/models"
+	"spendon/notify"
+	"spendon/storage"
+)
+
+func (server *Server) getTrash(rw http.ResponseWriter, r *http.Request) {
+	dbLogin := LoginFrom(r.Context())
+
+	trashedTransactions, err := storage.GetTrashedTransactions(dbLogin.Id)
+	if err != nil {
+		fmt.Println("Trash fetching error:", err)
+		writeServerError(rw)
+		return
+	}
+	writeJSON(rw, http.StatusOK, trashedTransactions)
+}
+
+func (server *Server) restoreFromTrash(rw http.ResponseWriter, r *http.Request) {
+	dbLogin := LoginFrom(r.Context())
+
+	trashRequest := models.TrashRequest{}
+	err := decodeBody(r, &trashRequest)
+	if err != nil {
+		writeBodyError(rw, err)
+		return
+	}
+	restored, err := storage.RestoreFromTrash(&trashRequest, dbLogin.Id, dbLogin.Id)
+	if err != nil {
+		fmt.Println("Restore from trash error:", err)
+		writeServerError(rw)
+		return
+	}
+	fmt.Println("Restored from trash:", restored)
+	go notify.CheckBudgets(dbLogin)
+}
+
+func (server *Server) purgeTrash(rw http.ResponseWriter, r *http.Request) {
+	dbLogin := LoginFrom(r.Context())
+
+	trashRequest := models.TrashRequest{}
+	err := decodeBody(r, &trashRequest)
+	if err != nil {
+		writeBodyError(rw, err)
+		return
+	}
+	purged, err := storage.PurgeTrash(&trashRequest, dbLogin.Id, dbLogin.Id)
+	if err != nil {
+		fmt.Println("Purge trash error:", err)
+		writeServerError(rw)
+		return
+	}
+	fmt.Println("Purged from trash:", purged)
+}
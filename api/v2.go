@@ -0,0 +1,186 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"spendon/models"
+	"spendon/storage"
+	"strconv"
+)
+
+const (
+	v2Prefix        = "/api/v2"
+	defaultPageSize = 50
+)
+
+// transactionQueryFilters maps the query parameters of the v2 list and stats endpoints to filters.
+var transactionQueryFilters = []struct {
+	param       string
+	property    int
+	operator    int
+	description string
+}{
+	{"from", models.SpentAt, models.GreaterOrEqual, "Spent at or after, like 2006-01-02T15:04:05"},
+	{"before", models.SpentAt, models.Less, "Spent before, like 2006-01-02T15:04:05"},
+	{"category", models.CategoryId, models.Equal, "Category id"},
+	{"minAmount", models.Amount, models.GreaterOrEqual, "Smallest amount"},
+	{"maxAmount", models.Amount, models.LessOrEqual, "Largest amount"},
+}
+
+func (server *Server) getProfileV2(rw http.ResponseWriter, r *http.Request) {
+	profile, err := server.service.Profile(LoginFrom(r.Context()))
+	if err != nil {
+		fmt.Println("Profile fetching error:", err)
+		writeServerError(rw)
+		return
+	}
+	writeJSON(rw, http.StatusOK, profile)
+}
+
+func (server *Server) listTransactionsV2(rw http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filteredRequest := models.FilteredRequest{Filters: filtersFromQuery(query)}
+	var fieldErrors []models.FieldError
+	filteredRequest.PageNumber, fieldErrors = queryInt(query, "page", 0, fieldErrors)
+	filteredRequest.Pagination, fieldErrors = queryInt(query, "pageSize", defaultPageSize, fieldErrors)
+	if len(fieldErrors) > 0 {
+		writeError(rw, models.ValidationFailedCode, "Request is not valid!", fieldErrors...)
+		return
+	}
+	if !server.validate(rw, &filteredRequest) {
+		return
+	}
+	transactions, err := server.service.ListTransactions(LoginFrom(r.Context()), &filteredRequest)
+	if err != nil {
+		fmt.Println("Fetching transactions error:", err)
+		writeServerError(rw)
+		return
+	}
+	writeJSON(rw, http.StatusOK, transactions)
+}
+
+func (server *Server) createTransactionV2(rw http.ResponseWriter, r *http.Request) {
+	dbLogin := LoginFrom(r.Context())
+	transaction := models.Transaction{}
+	if !server.readRequest(rw, r, &transaction) {
+		return
+	}
+
+	idempotencyKey := r.Header.Get("Idempotency-Key")
+	if !server.reserveIdempotencyKey(rw, r, dbLogin.Id, idempotencyKey, transaction) {
+		return
+	}
+	err := server.service.CreateTransaction(dbLogin, &transaction)
+	if err != nil {
+		fmt.Println("Insert transaction error:", err)
+		releaseIdempotencyKey(dbLogin.Id, idempotencyKey)
+		writeServerError(rw)
+		return
+	}
+	rw.Header().Set("Location", fmt.Sprintf("%s/transactions/%d", v2Prefix, transaction.Id))
+	rw.Header().Set("ETag", transactionETag(&transaction))
+	writeIdempotentResponse(rw, dbLogin.Id, idempotencyKey, http.StatusCreated, transaction)
+}
+
+func (server *Server) getTransactionV2(rw http.ResponseWriter, r *http.Request) {
+	id, ok := transactionIdFrom(rw, r)
+	if !ok {
+		return
+	}
+	transaction, err := server.service.GetTransaction(LoginFrom(r.Context()), id)
+	if errors.Is(err, storage.ErrNotFound) {
+		writeError(rw, models.NotFoundCode, "Transaction was not found!")
+		return
+	}
+	if err != nil {
+		fmt.Println("Fetching transaction error:", err)
+		writeServerError(rw)
+		return
+	}
+	rw.Header().Set("ETag", transactionETag(transaction))
+	writeJSON(rw, http.StatusOK, transaction)
+}
+
+func (server *Server) updateTransactionV2(rw http.ResponseWriter, r *http.Request) {
+	id, ok := transactionIdFrom(rw, r)
+	if !ok {
+		return
+	}
+	transaction := models.Transaction{}
+	if !server.readRequest(rw, r, &transaction) {
+		return
+	}
+	if transaction.Id != 0 && transaction.Id != id {
+		writeError(rw, models.ValidationFailedCode, "Request is not valid!", models.FieldError{Field: "Id", Message: "does not match the transaction in the path"})
+		return
+	}
+	transaction.Id = id
+	server.writeUpdatedTransaction(rw, r, LoginFrom(r.Context()), &transaction)
+}
+
+func (server *Server) removeTransactionV2(rw http.ResponseWriter, r *http.Request) {
+	id, ok := transactionIdFrom(rw, r)
+	if !ok {
+		return
+	}
+	if server.removeTransactionById(rw, LoginFrom(r.Context()), id) {
+		rw.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (server *Server) getCategoryStatsV2(rw http.ResponseWriter, r *http.Request) {
+	filterBatch := filtersFromQuery(r.URL.Query())
+	if !server.validate(rw, filterBatch) {
+		return
+	}
+	categorySummaries, err := server.service.CategoryStats(LoginFrom(r.Context()), filterBatch)
+	if err != nil {
+		fmt.Println("Fetching transactions error:", err)
+		writeServerError(rw)
+		return
+	}
+	writeJSON(rw, http.StatusOK, categorySummaries)
+}
+
+// transactionIdFrom writes the error response itself when the {id} path segment is not an id.
+func transactionIdFrom(rw http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(PathParam(r, "id"), 10, 64)
+	if err != nil || id <= 0 {
+		writeError(rw, models.NotFoundCode, "Transaction was not found!")
+		return 0, false
+	}
+	return id, true
+}
+
+// filtersFromQuery leaves checking the values to validation, so a bad one is reported by its filter.
+func filtersFromQuery(query url.Values) models.FilterBatch {
+	filterBatch := make(models.FilterBatch, 0)
+	for _, filter := range transactionQueryFilters {
+		if value := query.Get(filter.param); value != "" {
+			filterBatch = append(filterBatch, models.FilterModel{Property: filter.property, Operator: filter.operator, Value: value})
+		}
+	}
+	return filterBatch
+}
+
+func queryInt(query url.Values, param string, defaultValue int64, fieldErrors []models.FieldError) (int64, []models.FieldError) {
+	value := query.Get(param)
+	if value == "" {
+		return defaultValue, fieldErrors
+	}
+	number, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, append(fieldErrors, models.FieldError{Field: param, Message: "must be a whole number"})
+	}
+	return number, fieldErrors
+}
+
+// withTransactionFilters documents the query parameters read by filtersFromQuery.
+func withTransactionFilters(op operation) operation {
+	for _, filter := range transactionQueryFilters {
+		op = op.withQuery(filter.param, filter.description)
+	}
+	return op
+}
@@ -0,0 +1,148 @@
+package backup
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"spendon/models"
+)
+
+const (
+	manifestFile       = "manifest.json"
+	profileFile        = "profile.json"
+	categoriesFile     = "categories.json"
+	transactionsFile   = "transactions.json"
+	recurringFile      = "recurring.json"
+	budgetsFile        = "budgets.json"
+	savingsGoalsFile   = "goals.json"
+	importMappingsFile = "importmappings.json"
+	// maxEntrySize bounds what a single archive entry may unpack to. Entries are decoded in memory,
+	// so it is kept at the size of the upload an archive arrives in.
+	maxEntrySize = 64 << 20
+)
+
+var errEntryTooLarge = fmt.Errorf("entry is larger than %d MB", maxEntrySize>>20)
+
+// Write stores backup as a zip of JSON files. Transactions are not taken from backup but
+// streamed from stream into the archive, so they never have to be held in memory.
+func Write(writer io.Writer, backup *models.Backup, stream func(visit func(transaction *models.Transaction) error) error) error {
+	archive := zip.NewWriter(writer)
+	files := []struct {
+		name  string
+		value interface{}
+	}{
+		{manifestFile, backup.Manifest},
+		{profileFile, backup.Profile},
+		{categoriesFile, backup.Categories},
+		{recurringFile, backup.RecurringRules},
+		{budgetsFile, backup.Budgets},
+		{savingsGoalsFile, backup.SavingsGoals},
+		{importMappingsFile, backup.ImportMappings},
+	}
+	for _, file := range files {
+		fileWriter, err := archive.Create(file.name)
+		if err != nil {
+			return err
+		}
+		encoder := json.NewEncoder(fileWriter)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(file.value)
+		if err != nil {
+			return err
+		}
+	}
+	err := writeTransactions(archive, stream)
+	if err != nil {
+		return err
+	}
+	return archive.Close()
+}
+
+func writeTransactions(archive *zip.Writer, stream func(visit func(transaction *models.Transaction) error) error) error {
+	fileWriter, err := archive.Create(transactionsFile)
+	if err != nil {
+		return err
+	}
+	separator := "[\n"
+	err = stream(func(transaction *models.Transaction) error {
+		_, err := io.WriteString(fileWriter, separator)
+		if err != nil {
+			return err
+		}
+		separator = ",\n"
+		encoded, err := json.Marshal(transaction)
+		if err != nil {
+			return err
+		}
+		_, err = fileWriter.Write(encoded)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if separator == "[\n" {
+		_, err = io.WriteString(fileWriter, "[]\n")
+	} else {
+		_, err = io.WriteString(fileWriter, "\n]\n")
+	}
+	return err
+}
+
+// Read loads an archive written by Write. Missing files are left empty, so archives written
+// before a file was added stay restorable.
+func Read(reader io.ReaderAt, size int64) (*models.Backup, error) {
+	archive, err := zip.NewReader(reader, size)
+	if err != nil {
+		return nil, err
+	}
+	backup := models.Backup{}
+	targets := map[string]interface{}{
+		manifestFile:       &backup.Manifest,
+		profileFile:        &backup.Profile,
+		categoriesFile:     &backup.Categories,
+		transactionsFile:   &backup.Transactions,
+		recurringFile:      &backup.RecurringRules,
+		budgetsFile:        &backup.Budgets,
+		savingsGoalsFile:   &backup.SavingsGoals,
+		importMappingsFile: &backup.ImportMappings,
+	}
+	for _, file := range archive.File {
+		target, ok := targets[file.Name]
+		if !ok {
+			continue
+		}
+		err = readFile(file, target)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file.Name, err)
+		}
+	}
+	if backup.Manifest.Version == 0 {
+		return nil, fmt.Errorf("archive has no backup manifest")
+	}
+	if backup.Manifest.Version > models.BackupVersion {
+		return nil, fmt.Errorf("backup version %d is newer than the supported version %d", backup.Manifest.Version, models.BackupVersion)
+	}
+	return &backup, nil
+}
+
+// readFile rejects entries over maxEntrySize by their header first; as the header may lie, the
+// decoder reads through a limit too.
+func readFile(file *zip.File, target interface{}) error {
+	if file.UncompressedSize64 > maxEntrySize {
+		return errEntryTooLarge
+	}
+	fileReader, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = fileReader.Close()
+	}()
+	limited := &io.LimitedReader{R: fileReader, N: maxEntrySize + 1}
+	err = json.NewDecoder(limited).Decode(target)
+	if limited.N == 0 {
+		return errEntryTooLarge
+	}
+	return err
+}
@@ -0,0 +1,84 @@
+package backup
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"spendon/models"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteAndRead(t *testing.T) {
+	original := models.Backup{
+		Manifest:   models.BackupManifest{Version: models.BackupVersion, CreatedAt: time.Date(2023, 4, 1, 0, 0, 0, 0, time.UTC), Login: "alice"},
+		Profile:    models.BackupProfile{Login: "alice", Currency: "UAH"},
+		Categories: models.Categories{{Id: 1, Name: "Food"}, {Id: 2, Name: "Others"}},
+	}
+	transactions := []models.Transaction{
+		{Id: 10, Amount: 12.5, SpentAt: "2023-03-01T10:00:00", CategoryId: 1, Note: "Lunch"},
+		{Id: 11, Amount: -100, SpentAt: "2023-03-02T00:00:00", CategoryId: 2},
+	}
+	buffer := &bytes.Buffer{}
+	err := Write(buffer, &original, func(visit func(transaction *models.Transaction) error) error {
+		for idx := range transactions {
+			err := visit(&transactions[idx])
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	restored, err := Read(bytes.NewReader(buffer.Bytes()), int64(buffer.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored.Manifest.Login != "alice" || restored.Profile.Currency != "UAH" || len(restored.Categories) != 2 {
+		t.Errorf("got %+v", restored)
+	}
+	if len(restored.Transactions) != len(transactions) {
+		t.Fatalf("got %d transactions, want %d", len(restored.Transactions), len(transactions))
+	}
+	for idx, transaction := range restored.Transactions {
+		if transaction != transactions[idx] {
+			t.Errorf("transaction %d is %+v, want %+v", idx, transaction, transactions[idx])
+		}
+	}
+}
+
+func TestReadRejectsOversizedEntry(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	archive := zip.NewWriter(buffer)
+	writer, err := archive.Create(manifestFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = io.WriteString(writer, `{"Version": 1}`)
+	writer, err = archive.Create(transactionsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Spaces compress well, so the archive stays small while the entry unpacks past the limit.
+	megabyte := strings.Repeat(" ", 1<<20)
+	for written := 0; written <= maxEntrySize; written += len(megabyte) {
+		_, err = io.WriteString(writer, megabyte)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = archive.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = Read(bytes.NewReader(buffer.Bytes()), int64(buffer.Len()))
+	if !errors.Is(err, errEntryTooLarge) {
+		t.Fatalf("got %v, want the entry size error", err)
+	}
+}
@@ -0,0 +1,251 @@
+// Package client calls the SpendOn API with the request and response types of spendon/models.
+//
+// Every call takes a context. Failed calls return *Error when the server answered with an error
+// and the transport error otherwise. Requests that can be repeated safely are retried on network
+// errors and 5xx answers, inserts carry an Idempotency-Key so a retry never inserts twice.
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"spendon/models"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultRetries = 3
+	defaultBackoff = 200 * time.Millisecond
+	// tokenMargin renews the token a bit before it expires, so a request does not race the expiry.
+	tokenMargin = time.Minute
+)
+
+type Client struct {
+	baseUrl    string
+	httpClient *http.Client
+	retries    int
+	backoff    time.Duration
+
+	mutex      sync.Mutex
+	token      string
+	expireDate time.Time
+	userName   string
+	password   string
+}
+
+type Option func(client *Client)
+
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(client *Client) {
+		client.httpClient = httpClient
+	}
+}
+
+// WithRetries sets how many times a failed request is repeated. The pause before a retry starts
+// at backoff and doubles every time.
+func WithRetries(retries int, backoff time.Duration) Option {
+	return func(client *Client) {
+		client.retries = retries
+		client.backoff = backoff
+	}
+}
+
+// WithToken uses a token received elsewhere. Without credentials it cannot be renewed.
+func WithToken(token string) Option {
+	return func(client *Client) {
+		client.token = token
+	}
+}
+
+// New returns a client of the server at baseUrl, like "https://spendon.example.com".
+func New(baseUrl string, options ...Option) *Client {
+	client := &Client{
+		baseUrl:    strings.TrimRight(baseUrl, "/"),
+		httpClient: http.DefaultClient,
+		retries:    defaultRetries,
+		backoff:    defaultBackoff,
+	}
+	for _, option := range options {
+		option(client)
+	}
+	return client
+}
+
+// Login signs in and keeps the credentials to sign in again when the token expires.
+func (client *Client) Login(ctx context.Context, userName, password string) error {
+	client.mutex.Lock()
+	client.userName, client.password = userName, password
+	client.mutex.Unlock()
+	return client.renewToken(ctx)
+}
+
+// Register creates the user and signs in as it.
+func (client *Client) Register(ctx context.Context, login, password string) error {
+	loginResult := models.LoginResult{}
+	err := client.send(ctx, call{method: http.MethodPost, path: "/api/register", body: models.RegisterModel{Login: login, Password: password}, public: true}, &loginResult)
+	if err != nil {
+		return err
+	}
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+	client.userName, client.password = login, password
+	client.token, client.expireDate = loginResult.Token, loginResult.ExpireDate
+	return nil
+}
+
+func (client *Client) renewToken(ctx context.Context) error {
+	client.mutex.Lock()
+	credentials := models.Login{UserName: client.userName, Password: client.password}
+	client.mutex.Unlock()
+	if credentials.UserName == "" {
+		return fmt.Errorf("client: no credentials to sign in with, call Login first")
+	}
+	loginResult := models.LoginResult{}
+	err := client.send(ctx, call{method: http.MethodPost, path: "/api/login", body: credentials, public: true}, &loginResult)
+	if err != nil {
+		return err
+	}
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+	client.token, client.expireDate = loginResult.Token, loginResult.ExpireDate
+	return nil
+}
+
+// currentToken renews the token first when it is about to expire and the credentials are known.
+func (client *Client) currentToken(ctx context.Context) (string, error) {
+	client.mutex.Lock()
+	expiring := client.userName != "" && !client.expireDate.IsZero() && time.Now().Add(tokenMargin).After(client.expireDate)
+	client.mutex.Unlock()
+	if expiring {
+		err := client.renewToken(ctx)
+		if err != nil {
+			return "", err
+		}
+	}
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+	return client.token, nil
+}
+
+func (client *Client) hasCredentials() bool {
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+	return client.userName != ""
+}
+
+// call describes one API request.
+type call struct {
+	method  string
+	path    string
+	body    interface{}
+	headers map[string]string
+	public  bool
+	// idempotent allows retries, it is set for reads and for inserts with an Idempotency-Key.
+	idempotent bool
+}
+
+// send encodes the body once, so every attempt sends the same bytes. A rejected token is renewed
+// once per call, on top of the retries.
+func (client *Client) send(ctx context.Context, request call, result interface{}) error {
+	var body []byte
+	if request.body != nil {
+		var err error
+		body, err = json.Marshal(request.body)
+		if err != nil {
+			return err
+		}
+	}
+	renewed := false
+	for {
+		err := client.sendWithRetries(ctx, request, body, result)
+		if apiError, ok := err.(*Error); ok && apiError.StatusCode == http.StatusUnauthorized &&
+			!request.public && !renewed && client.hasCredentials() {
+			renewed = true
+			err = client.renewToken(ctx)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		return err
+	}
+}
+
+func (client *Client) sendWithRetries(ctx context.Context, request call, body []byte, result interface{}) error {
+	backoff := client.backoff
+	for attempt := 0; ; attempt++ {
+		retry, err := client.attempt(ctx, request, body, result)
+		if err == nil || !retry || !request.idempotent || attempt >= client.retries {
+			return err
+		}
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+		backoff *= 2
+	}
+}
+
+// attempt sends the request once and reports whether a failure is worth retrying.
+func (client *Client) attempt(ctx context.Context, request call, body []byte, result interface{}) (bool, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	httpRequest, err := http.NewRequestWithContext(ctx, request.method, client.baseUrl+request.path, reader)
+	if err != nil {
+		return false, err
+	}
+	if body != nil {
+		httpRequest.Header.Set("Content-Type", "application/json")
+	}
+	for name, value := range request.headers {
+		httpRequest.Header.Set(name, value)
+	}
+	if !request.public {
+		token, err := client.currentToken(ctx)
+		if err != nil {
+			return false, err
+		}
+		httpRequest.Header.Set("Token", token)
+	}
+	response, err := client.httpClient.Do(httpRequest)
+	if err != nil {
+		return ctx.Err() == nil, err
+	}
+	defer func() {
+		_ = response.Body.Close()
+	}()
+	if response.StatusCode >= http.StatusBadRequest {
+		return response.StatusCode >= http.StatusInternalServerError || response.StatusCode == http.StatusTooManyRequests,
+			readError(response)
+	}
+	if result == nil || response.StatusCode == http.StatusNoContent {
+		return false, nil
+	}
+	err = json.NewDecoder(response.Body).Decode(result)
+	if err == io.EOF {
+		return false, nil
+	}
+	return false, err
+}
+
+// newIdempotencyKey is shared by every attempt of one insert.
+func newIdempotencyKey() (string, error) {
+	key := make([]byte, 16)
+	_, err := rand.Read(key)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(key), nil
+}
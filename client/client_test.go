@@ -0,0 +1,473 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"spendon/models"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeServer stands in for the SpendOn API. Handlers are registered per path and every request
+// is recorded, so a test can check what the client sent.
+type fakeServer struct {
+	*httptest.Server
+	mutex    sync.Mutex
+	handlers map[string]http.HandlerFunc
+	requests []recordedRequest
+}
+
+type recordedRequest struct {
+	method string
+	path   string
+	header http.Header
+	body   string
+}
+
+func newFakeServer(t *testing.T) *fakeServer {
+	server := &fakeServer{handlers: make(map[string]http.HandlerFunc)}
+	server.Server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		server.mutex.Lock()
+		server.requests = append(server.requests, recordedRequest{r.Method, r.URL.Path, r.Header.Clone(), string(body)})
+		handler, ok := server.handlers[r.URL.Path]
+		server.mutex.Unlock()
+		if !ok {
+			writeEnvelope(rw, http.StatusNotFound, models.ApiError{Code: models.NotFoundCode, Message: "Not found"})
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		handler(rw, r)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func (server *fakeServer) handle(path string, handler http.HandlerFunc) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	server.handlers[path] = handler
+}
+
+func (server *fakeServer) requestsTo(path string) []recordedRequest {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	result := make([]recordedRequest, 0)
+	for _, request := range server.requests {
+		if request.path == path {
+			result = append(result, request)
+		}
+	}
+	return result
+}
+
+// issueTokens answers logins with the given tokens in turn, each valid for validFor.
+func (server *fakeServer) issueTokens(validFor time.Duration, tokens ...string) {
+	issued := 0
+	server.handle("/api/login", func(rw http.ResponseWriter, r *http.Request) {
+		credentials := models.Login{}
+		_ = json.NewDecoder(r.Body).Decode(&credentials)
+		if credentials.UserName != "alice" || credentials.Password != "secret123" {
+			writeEnvelope(rw, http.StatusUnauthorized, models.ApiError{Code: models.UnauthorizedCode, Message: "Wrong login or password!"})
+			return
+		}
+		token := tokens[len(tokens)-1]
+		if issued < len(tokens) {
+			token = tokens[issued]
+		}
+		issued++
+		writeBody(rw, http.StatusOK, models.LoginResult{Token: token, ExpireDate: time.Now().Add(validFor)})
+	})
+}
+
+func writeEnvelope(rw http.ResponseWriter, status int, apiError models.ApiError) {
+	writeBody(rw, status, models.ErrorResponse{Error: apiError})
+}
+
+func writeBody(rw http.ResponseWriter, status int, value interface{}) {
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(status)
+	_ = json.NewEncoder(rw).Encode(value)
+}
+
+func newTestClient(server *fakeServer, options ...Option) *Client {
+	return New(server.URL+"/", append([]Option{WithRetries(3, time.Millisecond)}, options...)...)
+}
+
+func TestLoginFailureIsTypedError(t *testing.T) {
+	server := newFakeServer(t)
+	server.issueTokens(time.Hour, "t1")
+	client := newTestClient(server)
+
+	err := client.Login(context.Background(), "alice", "wrong")
+	if !HasCode(err, models.UnauthorizedCode) {
+		t.Fatalf("got %v, want an unauthorized error", err)
+	}
+	if got := len(server.requestsTo("/api/login")); got != 1 {
+		t.Errorf("login was sent %d times, want 1", got)
+	}
+}
+
+func TestRenewsTokenAfterUnauthorized(t *testing.T) {
+	server := newFakeServer(t)
+	server.issueTokens(time.Hour, "revoked", "fresh")
+	server.handle("/api/v2/me", func(rw http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Token") != "fresh" {
+			writeEnvelope(rw, http.StatusUnauthorized, models.ApiError{Code: models.UnauthorizedCode, Message: "Authorize failure!"})
+			return
+		}
+		writeBody(rw, http.StatusOK, models.Profile{Id: 7, Login: "alice"})
+	})
+	client := newTestClient(server)
+	err := client.Login(context.Background(), "alice", "secret123")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	profile, err := client.Profile(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profile.Id != 7 {
+		t.Errorf("got profile %+v", profile)
+	}
+	if got := len(server.requestsTo("/api/login")); got != 2 {
+		t.Errorf("signed in %d times, want 2", got)
+	}
+	tokens := []string{}
+	for _, request := range server.requestsTo("/api/v2/me") {
+		tokens = append(tokens, request.header.Get("Token"))
+	}
+	if len(tokens) != 2 || tokens[0] != "revoked" || tokens[1] != "fresh" {
+		t.Errorf("sent tokens %v, want [revoked fresh]", tokens)
+	}
+}
+
+func TestRenewsTokenOnlyOncePerCall(t *testing.T) {
+	server := newFakeServer(t)
+	server.issueTokens(time.Hour, "t1", "t2", "t3")
+	server.handle("/api/v2/me", func(rw http.ResponseWriter, r *http.Request) {
+		writeEnvelope(rw, http.StatusUnauthorized, models.ApiError{Code: models.UnauthorizedCode, Message: "Authorize failure!"})
+	})
+	client := newTestClient(server)
+	err := client.Login(context.Background(), "alice", "secret123")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = client.Profile(context.Background())
+	if !HasCode(err, models.UnauthorizedCode) {
+		t.Fatalf("got %v, want an unauthorized error", err)
+	}
+	if got := len(server.requestsTo("/api/v2/me")); got != 2 {
+		t.Errorf("profile was requested %d times, want 2", got)
+	}
+}
+
+func TestRenewsExpiringTokenBeforeRequest(t *testing.T) {
+	server := newFakeServer(t)
+	// The first token expires within tokenMargin, so it must be replaced before it is used.
+	server.issueTokens(tokenMargin/2, "expiring", "fresh")
+	server.handle("/api/v2/stats/budgets", func(rw http.ResponseWriter, r *http.Request) {
+		writeBody(rw, http.StatusOK, models.BudgetStatuses{})
+	})
+	client := newTestClient(server)
+	err := client.Login(context.Background(), "alice", "secret123")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = client.BudgetStatuses(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	requests := server.requestsTo("/api/v2/stats/budgets")
+	if len(requests) != 1 || requests[0].header.Get("Token") != "fresh" {
+		t.Errorf("got requests %+v, want one with the fresh token", requests)
+	}
+}
+
+func TestRetriesServerErrorsWithSameIdempotencyKey(t *testing.T) {
+	server := newFakeServer(t)
+	attempts := 0
+	server.handle("/api/v2/transactions", func(rw http.ResponseWriter, r *http.Request) {
+		attempts++
+		switch attempts {
+		case 1:
+			writeEnvelope(rw, http.StatusServiceUnavailable, models.ApiError{Code: models.InternalErrorCode, Message: "Unavailable"})
+		case 2:
+			writeEnvelope(rw, http.StatusTooManyRequests, models.ApiError{Message: "Slow down"})
+		default:
+			transaction := models.Transaction{}
+			_ = json.NewDecoder(r.Body).Decode(&transaction)
+			transaction.Id, transaction.Version = 42, 1
+			writeBody(rw, http.StatusCreated, transaction)
+		}
+	})
+	client := newTestClient(server, WithToken("token"))
+
+	created, err := client.AddTransaction(context.Background(), &models.Transaction{Amount: 9.5, CategoryId: 1, SpentAt: "2023-01-02T03:04:05"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created.Id != 42 || created.Amount != 9.5 {
+		t.Errorf("got %+v", created)
+	}
+	requests := server.requestsTo("/api/v2/transactions")
+	if len(requests) != 3 {
+		t.Fatalf("sent %d attempts, want 3", len(requests))
+	}
+	key := requests[0].header.Get("Idempotency-Key")
+	if key == "" {
+		t.Fatal("the insert carries no Idempotency-Key")
+	}
+	for idx, request := range requests {
+		if got := request.header.Get("Idempotency-Key"); got != key {
+			t.Errorf("attempt %d sent key %q, want %q", idx, got, key)
+		}
+		if request.body != requests[0].body {
+			t.Errorf("attempt %d sent body %s, want %s", idx, request.body, requests[0].body)
+		}
+	}
+
+	_, err = client.AddTransaction(context.Background(), &models.Transaction{Amount: 1, CategoryId: 1, SpentAt: "2023-01-02T03:04:05"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := server.requestsTo("/api/v2/transactions")[3].header.Get("Idempotency-Key"); got == key {
+		t.Error("a separate insert reused the Idempotency-Key")
+	}
+}
+
+func TestGivesUpAfterRetries(t *testing.T) {
+	server := newFakeServer(t)
+	server.handle("/api/v2/me", func(rw http.ResponseWriter, r *http.Request) {
+		writeEnvelope(rw, http.StatusInternalServerError, models.ApiError{Code: models.InternalErrorCode, Message: "Server error"})
+	})
+	client := New(server.URL, WithToken("token"), WithRetries(2, time.Millisecond))
+
+	_, err := client.Profile(context.Background())
+	if !HasCode(err, models.InternalErrorCode) {
+		t.Fatalf("got %v, want an internal error", err)
+	}
+	if got := len(server.requestsTo("/api/v2/me")); got != 3 {
+		t.Errorf("sent %d attempts, want 3", got)
+	}
+}
+
+func TestDoesNotRetryClientErrors(t *testing.T) {
+	server := newFakeServer(t)
+	server.handle("/api/v2/transactions", func(rw http.ResponseWriter, r *http.Request) {
+		writeEnvelope(rw, http.StatusBadRequest, models.ApiError{
+			Code:    models.ValidationFailedCode,
+			Message: "Request is not valid!",
+			Details: []models.FieldError{{Field: "Amount", Message: "is required"}},
+		})
+	})
+	client := newTestClient(server, WithToken("token"))
+
+	_, err := client.AddTransaction(context.Background(), &models.Transaction{})
+	var apiError *Error
+	if !errors.As(err, &apiError) {
+		t.Fatalf("got %v, want *Error", err)
+	}
+	if apiError.StatusCode != http.StatusBadRequest || apiError.Code != models.ValidationFailedCode ||
+		len(apiError.Details) != 1 || apiError.Details[0].Field != "Amount" {
+		t.Errorf("got %+v", apiError)
+	}
+	if got := len(server.requestsTo("/api/v2/transactions")); got != 1 {
+		t.Errorf("sent %d attempts, want 1", got)
+	}
+}
+
+func TestDoesNotRetryUpdates(t *testing.T) {
+	server := newFakeServer(t)
+	server.handle("/api/v2/transactions/5", func(rw http.ResponseWriter, r *http.Request) {
+		writeEnvelope(rw, http.StatusBadGateway, models.ApiError{Message: "Bad gateway"})
+	})
+	client := newTestClient(server, WithToken("token"))
+
+	_, err := client.UpdateTransaction(context.Background(), &models.Transaction{Id: 5, Version: 3})
+	if err == nil {
+		t.Fatal("the update succeeded")
+	}
+	requests := server.requestsTo("/api/v2/transactions/5")
+	if len(requests) != 1 {
+		t.Fatalf("sent %d attempts, want 1", len(requests))
+	}
+	if got := requests[0].header.Get("If-Match"); got != "\"3\"" {
+		t.Errorf("sent If-Match %q, want \"3\"", got)
+	}
+}
+
+func TestCancelDuringBackoff(t *testing.T) {
+	server := newFakeServer(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	server.handle("/api/v2/categories", func(rw http.ResponseWriter, r *http.Request) {
+		writeEnvelope(rw, http.StatusServiceUnavailable, models.ApiError{Message: "Unavailable"})
+		// Cancelled while the client waits out its backoff, which is far longer than the test.
+		time.AfterFunc(10*time.Millisecond, cancel)
+	})
+	client := New(server.URL, WithRetries(5, time.Hour))
+
+	started := time.Now()
+	_, err := client.Categories(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("got %v, want context.Canceled", err)
+	}
+	if elapsed := time.Since(started); elapsed > 5*time.Second {
+		t.Errorf("returned after %v", elapsed)
+	}
+	if got := len(server.requestsTo("/api/v2/categories")); got != 1 {
+		t.Errorf("sent %d attempts, want 1", got)
+	}
+}
+
+func TestDecodesVersionConflict(t *testing.T) {
+	server := newFakeServer(t)
+	stored := models.Transaction{Id: 5, Amount: 20, CategoryId: 2, SpentAt: "2023-01-02T03:04:05", Version: 4}
+	server.handle("/api/v2/transactions/5", func(rw http.ResponseWriter, r *http.Request) {
+		writeEnvelope(rw, http.StatusConflict, models.ApiError{
+			Code:      models.VersionConflictCode,
+			Message:   "Transaction was changed by someone else!",
+			Current:   stored,
+			RequestId: "req-1",
+		})
+	})
+	client := newTestClient(server, WithToken("token"))
+
+	_, err := client.UpdateTransaction(context.Background(), &models.Transaction{Id: 5, Version: 3})
+	var apiError *Error
+	if !errors.As(err, &apiError) {
+		t.Fatalf("got %v, want *Error", err)
+	}
+	if apiError.StatusCode != http.StatusConflict || apiError.Code != models.VersionConflictCode || apiError.RequestId != "req-1" {
+		t.Errorf("got %+v", apiError)
+	}
+	current, err := apiError.CurrentTransaction()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *current != stored {
+		t.Errorf("got current %+v, want %+v", *current, stored)
+	}
+}
+
+func TestDecodesErrorsWithoutEnvelope(t *testing.T) {
+	server := newFakeServer(t)
+	server.handle("/api/v2/categories", func(rw http.ResponseWriter, r *http.Request) {
+		rw.Header().Set("Content-Type", "text/html")
+		rw.WriteHeader(http.StatusBadGateway)
+		_, _ = io.WriteString(rw, "<html>proxy error</html>")
+	})
+	client := New(server.URL, WithRetries(0, time.Millisecond))
+
+	_, err := client.Categories(context.Background())
+	var apiError *Error
+	if !errors.As(err, &apiError) {
+		t.Fatalf("got %v, want *Error", err)
+	}
+	if apiError.StatusCode != http.StatusBadGateway || apiError.Code != "" || apiError.Message != "Bad Gateway" {
+		t.Errorf("got %+v", apiError)
+	}
+}
+
+// serveTransactions answers fetches from transactions page by page, failing the page failPage.
+func serveTransactions(server *fakeServer, transactions []models.Transaction, failPage int64) {
+	server.handle("/api/fetchtransactions", func(rw http.ResponseWriter, r *http.Request) {
+		request := models.FilteredRequest{}
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		if request.PageNumber == failPage {
+			writeEnvelope(rw, http.StatusBadRequest, models.ApiError{Code: models.ValidationFailedCode, Message: "Bad page"})
+			return
+		}
+		start := request.PageNumber * request.Pagination
+		end := start + request.Pagination
+		if start > int64(len(transactions)) {
+			start = int64(len(transactions))
+		}
+		if end > int64(len(transactions)) {
+			end = int64(len(transactions))
+		}
+		writeBody(rw, http.StatusOK, models.PagedTransactions{Transactions: transactions[start:end], Count: int64(len(transactions))})
+	})
+}
+
+func TestTransactionIterator(t *testing.T) {
+	transactions := make([]models.Transaction, 5)
+	for idx := range transactions {
+		transactions[idx] = models.Transaction{Id: int64(100 - idx), Amount: float32(idx)}
+	}
+	tests := []struct {
+		name     string
+		pageSize int64
+		pages    int
+	}{
+		{"partial last page", 2, 3},
+		{"exact pages", 5, 1},
+		{"default page size", 0, 1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := newFakeServer(t)
+			serveTransactions(server, transactions, -1)
+			client := newTestClient(server, WithToken("token"))
+
+			iterator := client.Transactions(models.FilterBatch{}, test.pageSize)
+			ids := make([]int64, 0)
+			for iterator.Next(context.Background()) {
+				ids = append(ids, iterator.Transaction().Id)
+			}
+			if iterator.Err() != nil {
+				t.Fatal(iterator.Err())
+			}
+			if len(ids) != len(transactions) {
+				t.Fatalf("got ids %v", ids)
+			}
+			for idx, id := range ids {
+				if id != transactions[idx].Id {
+					t.Errorf("got ids %v", ids)
+					break
+				}
+			}
+			if iterator.Total() != int64(len(transactions)) {
+				t.Errorf("got total %d", iterator.Total())
+			}
+			if got := len(server.requestsTo("/api/fetchtransactions")); got != test.pages {
+				t.Errorf("fetched %d pages, want %d", got, test.pages)
+			}
+			if iterator.Next(context.Background()) {
+				t.Error("Next reported more transactions after the end")
+			}
+		})
+	}
+}
+
+func TestTransactionIteratorEmptyAndFailing(t *testing.T) {
+	server := newFakeServer(t)
+	serveTransactions(server, nil, -1)
+	client := newTestClient(server, WithToken("token"))
+	iterator := client.Transactions(nil, 10)
+	if iterator.Next(context.Background()) || iterator.Err() != nil {
+		t.Errorf("an empty result gave a transaction or error %v", iterator.Err())
+	}
+
+	server = newFakeServer(t)
+	serveTransactions(server, make([]models.Transaction, 3), 1)
+	client = newTestClient(server, WithToken("token"))
+	iterator = client.Transactions(nil, 2)
+	count := 0
+	for iterator.Next(context.Background()) {
+		count++
+	}
+	if count != 2 || !HasCode(iterator.Err(), models.ValidationFailedCode) {
+		t.Errorf("got %d transactions and error %v, want 2 and the page error", count, iterator.Err())
+	}
+}
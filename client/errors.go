@@ -0,0 +1,55 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"spendon/models"
+)
+
+// Error is an error answer of the server. Code is one of the error codes of spendon/models, or
+// empty when the answer did not come from SpendOn, like one from a proxy.
+type Error struct {
+	StatusCode int
+	models.ApiError
+}
+
+func (apiError *Error) Error() string {
+	if apiError.RequestId != "" {
+		return fmt.Sprintf("spendon: %d %s: %s (request %s)", apiError.StatusCode, apiError.Code, apiError.Message, apiError.RequestId)
+	}
+	return fmt.Sprintf("spendon: %d %s: %s", apiError.StatusCode, apiError.Code, apiError.Message)
+}
+
+// HasCode reports whether err is an error answer with code.
+func HasCode(err error, code string) bool {
+	var apiError *Error
+	return errors.As(err, &apiError) && apiError.Code == code
+}
+
+// CurrentTransaction returns the stored transaction sent with a version_conflict error.
+func (apiError *Error) CurrentTransaction() (*models.Transaction, error) {
+	if apiError.Current == nil {
+		return nil, fmt.Errorf("client: the error carries no current transaction")
+	}
+	current, err := json.Marshal(apiError.Current)
+	if err != nil {
+		return nil, err
+	}
+	transaction := models.Transaction{}
+	err = json.Unmarshal(current, &transaction)
+	return &transaction, err
+}
+
+// readError keeps the status when the body is not an error envelope, like one from a proxy.
+func readError(response *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(response.Body, 1<<20))
+	envelope := models.ErrorResponse{}
+	err := json.Unmarshal(body, &envelope)
+	if err != nil || envelope.Error.Code == "" {
+		return &Error{StatusCode: response.StatusCode, ApiError: models.ApiError{Message: http.StatusText(response.StatusCode)}}
+	}
+	return &Error{StatusCode: response.StatusCode, ApiError: envelope.Error}
+}
@@ -0,0 +1,195 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"spendon/models"
+	"strconv"
+)
+
+const defaultPageSize = 100
+
+// AddTransaction inserts the transaction and returns it with its id and version.
+func (client *Client) AddTransaction(ctx context.Context, transaction *models.Transaction) (*models.Transaction, error) {
+	key, err := newIdempotencyKey()
+	if err != nil {
+		return nil, err
+	}
+	created := models.Transaction{}
+	err = client.send(ctx, call{
+		method:     http.MethodPost,
+		path:       "/api/v2/transactions",
+		body:       transaction,
+		headers:    map[string]string{"Idempotency-Key": key},
+		idempotent: true,
+	}, &created)
+	if err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// BulkAddTransactions inserts the transactions in one request. Items that have a ClientId are
+// inserted once even across separate calls; items the user already has by ExternalId are skipped.
+func (client *Client) BulkAddTransactions(ctx context.Context, transactions models.BulkTransactions) (models.BulkTransactions, error) {
+	key, err := newIdempotencyKey()
+	if err != nil {
+		return nil, err
+	}
+	inserted := make(models.BulkTransactions, 0, len(transactions))
+	err = client.send(ctx, call{
+		method:     http.MethodPost,
+		path:       "/api/bulkadd",
+		body:       transactions,
+		headers:    map[string]string{"Idempotency-Key": key},
+		idempotent: true,
+	}, &inserted)
+	return inserted, err
+}
+
+// GetTransaction returns an *Error with the not_found code for missing and deleted transactions.
+func (client *Client) GetTransaction(ctx context.Context, id int64) (*models.Transaction, error) {
+	transaction := models.Transaction{}
+	err := client.send(ctx, call{method: http.MethodGet, path: transactionPath(id), idempotent: true}, &transaction)
+	if err != nil {
+		return nil, err
+	}
+	return &transaction, nil
+}
+
+// UpdateTransaction applies the change when transaction.Version is still the stored version.
+// Otherwise it returns an *Error with the version_conflict code, whose CurrentTransaction is the
+// stored one. Updates are not retried, as a repeated update would conflict with the first one.
+func (client *Client) UpdateTransaction(ctx context.Context, transaction *models.Transaction) (*models.Transaction, error) {
+	updated := models.Transaction{}
+	err := client.send(ctx, call{
+		method:  http.MethodPut,
+		path:    transactionPath(transaction.Id),
+		body:    transaction,
+		headers: map[string]string{"If-Match": fmt.Sprintf("\"%d\"", transaction.Version)},
+	}, &updated)
+	if err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// RemoveTransaction moves the transaction to the trash.
+func (client *Client) RemoveTransaction(ctx context.Context, id int64) error {
+	return client.send(ctx, call{method: http.MethodDelete, path: transactionPath(id)}, nil)
+}
+
+// FetchTransactions returns one page of the transactions matching request.Filters, newest first.
+func (client *Client) FetchTransactions(ctx context.Context, request models.FilteredRequest) (models.PagedTransactions, error) {
+	if request.Filters == nil {
+		request.Filters = models.FilterBatch{}
+	}
+	paged := models.PagedTransactions{}
+	// Fetching is a read, even though v1 sends it as POST.
+	err := client.send(ctx, call{method: http.MethodPost, path: "/api/fetchtransactions", body: request, idempotent: true}, &paged)
+	return paged, err
+}
+
+// Transactions returns an iterator over every transaction matching filters, fetched pageSize at
+// a time. A zero pageSize means 100.
+func (client *Client) Transactions(filters models.FilterBatch, pageSize int64) *TransactionIterator {
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+	return &TransactionIterator{client: client, request: models.FilteredRequest{Pagination: pageSize, Filters: filters}}
+}
+
+// TransactionIterator is used like bufio.Scanner:
+//
+//	iterator := client.Transactions(filters, 0)
+//	for iterator.Next(ctx) {
+//		transaction := iterator.Transaction()
+//	}
+//	err := iterator.Err()
+type TransactionIterator struct {
+	client  *Client
+	request models.FilteredRequest
+	page    []models.Transaction
+	index   int
+	fetched int64
+	total   int64
+	started bool
+	err     error
+}
+
+// Next moves to the next transaction, fetching the next page when the current one is used up.
+func (iterator *TransactionIterator) Next(ctx context.Context) bool {
+	if iterator.err != nil {
+		return false
+	}
+	iterator.index++
+	if iterator.index < len(iterator.page) {
+		return true
+	}
+	if iterator.started && (iterator.fetched >= iterator.total || int64(len(iterator.page)) < iterator.request.Pagination) {
+		return false
+	}
+	paged, err := iterator.client.FetchTransactions(ctx, iterator.request)
+	if err != nil {
+		iterator.err = err
+		return false
+	}
+	iterator.started = true
+	iterator.request.PageNumber++
+	iterator.page, iterator.index = paged.Transactions, 0
+	iterator.fetched += int64(len(paged.Transactions))
+	iterator.total = paged.Count
+	return len(iterator.page) > 0
+}
+
+func (iterator *TransactionIterator) Transaction() models.Transaction {
+	return iterator.page[iterator.index]
+}
+
+// Total is the number of matching transactions reported with the last page.
+func (iterator *TransactionIterator) Total() int64 {
+	return iterator.total
+}
+
+func (iterator *TransactionIterator) Err() error {
+	return iterator.err
+}
+
+// Categories are shared by every user.
+func (client *Client) Categories(ctx context.Context) (models.Categories, error) {
+	categories := make(models.Categories, 0)
+	err := client.send(ctx, call{method: http.MethodGet, path: "/api/v2/categories", public: true, idempotent: true}, &categories)
+	return categories, err
+}
+
+// CategoryStats sums the transactions matching filters by category.
+func (client *Client) CategoryStats(ctx context.Context, filters models.FilterBatch) (models.CategoriesSummary, error) {
+	if filters == nil {
+		filters = models.FilterBatch{}
+	}
+	summary := make(models.CategoriesSummary, 0)
+	err := client.send(ctx, call{method: http.MethodPost, path: "/api/getcategoriesstats", body: filters, idempotent: true}, &summary)
+	return summary, err
+}
+
+// BudgetStatuses shows the spending of the current period of every budget.
+func (client *Client) BudgetStatuses(ctx context.Context) (models.BudgetStatuses, error) {
+	statuses := make(models.BudgetStatuses, 0)
+	err := client.send(ctx, call{method: http.MethodGet, path: "/api/v2/stats/budgets", idempotent: true}, &statuses)
+	return statuses, err
+}
+
+// Profile describes the signed in user.
+func (client *Client) Profile(ctx context.Context) (*models.Profile, error) {
+	profile := models.Profile{}
+	err := client.send(ctx, call{method: http.MethodGet, path: "/api/v2/me", idempotent: true}, &profile)
+	if err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+func transactionPath(id int64) string {
+	return "/api/v2/transactions/" + strconv.FormatInt(id, 10)
+}
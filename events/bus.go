@@ -0,0 +1,125 @@
+package events
+
+import (
+	"fmt"
+	"spendon/models"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// subscriberBuffer is how many events a subscriber may fall behind before it is dropped.
+const subscriberBuffer = 64
+
+// Bus hands the events published by the storage layer to the subscribed streams and keeps the
+// latest of them, so a reconnecting client receives what it missed.
+type Bus struct {
+	mutex       sync.Mutex
+	epoch       string
+	sequence    uint64
+	history     []models.Event
+	historySize int
+	subscribers map[*Subscription]struct{}
+}
+
+// Subscription receives the events of one user until it is closed. Events is closed when the
+// subscriber falls too far behind, after which the client has to reconnect.
+type Subscription struct {
+	Events <-chan models.Event
+	events chan models.Event
+	userId int64
+	bus    *Bus
+}
+
+// NewBus keeps the last historySize events for resuming. Event ids start with the start time of the
+// bus, so ids issued before a restart are recognised as unknown instead of being misread.
+func NewBus(historySize int) *Bus {
+	return &Bus{
+		epoch:       strconv.FormatInt(time.Now().UnixNano(), 36),
+		historySize: historySize,
+		subscribers: make(map[*Subscription]struct{}),
+	}
+}
+
+// Publish assigns the event its id and sends it to the subscribers of its user, or to everyone
+// when it has no user.
+func (bus *Bus) Publish(event models.Event) {
+	bus.mutex.Lock()
+	defer bus.mutex.Unlock()
+
+	bus.sequence++
+	event.Id = fmt.Sprintf("%s-%d", bus.epoch, bus.sequence)
+	bus.history = append(bus.history, event)
+	if len(bus.history) > bus.historySize {
+		bus.history = bus.history[len(bus.history)-bus.historySize:]
+	}
+	for subscription := range bus.subscribers {
+		if !subscription.receives(&event) {
+			continue
+		}
+		select {
+		case subscription.events <- event:
+		default:
+			fmt.Println("Dropping slow event subscriber of user", subscription.userId)
+			bus.remove(subscription)
+		}
+	}
+}
+
+// Subscribe starts a subscription of the user. With lastEventId it also returns the events
+// published after that one; resumed is false when they are no longer kept and the client has to
+// reload its data.
+func (bus *Bus) Subscribe(userId int64, lastEventId string) (subscription *Subscription, missed []models.Event, resumed bool) {
+	events := make(chan models.Event, subscriberBuffer)
+	subscription = &Subscription{Events: events, events: events, userId: userId, bus: bus}
+
+	bus.mutex.Lock()
+	defer bus.mutex.Unlock()
+
+	bus.subscribers[subscription] = struct{}{}
+	if lastEventId == "" {
+		return subscription, nil, true
+	}
+	sequence, ok := bus.sequenceOf(lastEventId)
+	if !ok || sequence > bus.sequence {
+		return subscription, nil, false
+	}
+	firstKept := bus.sequence - uint64(len(bus.history)) + 1
+	if sequence+1 < firstKept {
+		return subscription, nil, false
+	}
+	for _, event := range bus.history[sequence+1-firstKept:] {
+		if subscription.receives(&event) {
+			missed = append(missed, event)
+		}
+	}
+	return subscription, missed, true
+}
+
+// Close stops the subscription. It is safe to call more than once.
+func (subscription *Subscription) Close() {
+	subscription.bus.mutex.Lock()
+	defer subscription.bus.mutex.Unlock()
+	subscription.bus.remove(subscription)
+}
+
+func (subscription *Subscription) receives(event *models.Event) bool {
+	return event.UserId == 0 || event.UserId == subscription.userId
+}
+
+func (bus *Bus) remove(subscription *Subscription) {
+	if _, ok := bus.subscribers[subscription]; ok {
+		delete(bus.subscribers, subscription)
+		close(subscription.events)
+	}
+}
+
+func (bus *Bus) sequenceOf(eventId string) (uint64, bool) {
+	idx := strings.LastIndex(eventId, "-")
+	if idx < 0 || eventId[:idx] != bus.epoch {
+		return 0, false
+	}
+	sequence, err := strconv.ParseUint(eventId[idx+1:], 10, 64)
+	return sequence, err == nil
+}
@@ -0,0 +1,153 @@
+package events
+
+import (
+	"spendon/models"
+	"testing"
+)
+
+// publish sends one event per transaction id and returns the ids the bus gave them.
+func publish(t *testing.T, bus *Bus, userId int64, transactionIds ...int64) []string {
+	t.Helper()
+	watcher, _, _ := bus.Subscribe(userId, "")
+	defer watcher.Close()
+	ids := make([]string, 0, len(transactionIds))
+	for _, transactionId := range transactionIds {
+		bus.Publish(models.Event{Type: models.TransactionCreatedEvent, UserId: userId, TransactionId: transactionId})
+		ids = append(ids, (<-watcher.Events).Id)
+	}
+	return ids
+}
+
+func transactionIds(events []models.Event) []int64 {
+	ids := make([]int64, 0, len(events))
+	for _, event := range events {
+		ids = append(ids, event.TransactionId)
+	}
+	return ids
+}
+
+func sameIds(got, want []int64) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for idx := range got {
+		if got[idx] != want[idx] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestSubscribeResumesAfterLastEvent(t *testing.T) {
+	bus := NewBus(10)
+	ids := publish(t, bus, 1, 1, 2, 3)
+
+	subscription, missed, resumed := bus.Subscribe(1, ids[0])
+	defer subscription.Close()
+	if !resumed {
+		t.Fatal("a kept event id was not resumed")
+	}
+	if got := transactionIds(missed); !sameIds(got, []int64{2, 3}) {
+		t.Errorf("got missed transactions %v, want [2 3]", got)
+	}
+
+	subscription, missed, resumed = bus.Subscribe(1, ids[2])
+	defer subscription.Close()
+	if !resumed || len(missed) != 0 {
+		t.Errorf("resuming after the latest event got %v, resumed %v", missed, resumed)
+	}
+}
+
+func TestSubscribeRefusesIdsOfAnotherEpoch(t *testing.T) {
+	previous := NewBus(10)
+	ids := publish(t, previous, 1, 1)
+	bus := NewBus(10)
+	bus.epoch = previous.epoch + "x"
+	publish(t, bus, 1, 1, 2)
+
+	for _, lastEventId := range []string{ids[0], "1", bus.epoch + "-9", bus.epoch + "-first"} {
+		subscription, missed, resumed := bus.Subscribe(1, lastEventId)
+		subscription.Close()
+		if resumed || len(missed) != 0 {
+			t.Errorf("%q was resumed with %v", lastEventId, missed)
+		}
+	}
+}
+
+func TestSubscribeRefusesEvictedIds(t *testing.T) {
+	bus := NewBus(2)
+	ids := publish(t, bus, 1, 1, 2, 3, 4)
+
+	subscription, missed, resumed := bus.Subscribe(1, ids[0])
+	subscription.Close()
+	if resumed || len(missed) != 0 {
+		t.Errorf("an evicted id was resumed with %v", missed)
+	}
+
+	subscription, missed, resumed = bus.Subscribe(1, ids[1])
+	subscription.Close()
+	if !resumed {
+		t.Fatal("the id right before the kept events was not resumed")
+	}
+	if got := transactionIds(missed); !sameIds(got, []int64{3, 4}) {
+		t.Errorf("got missed transactions %v, want [3 4]", got)
+	}
+}
+
+func TestPublishDropsSlowSubscriber(t *testing.T) {
+	bus := NewBus(10)
+	slow, _, _ := bus.Subscribe(1, "")
+	defer slow.Close()
+
+	for idx := 0; idx <= subscriberBuffer; idx++ {
+		bus.Publish(models.Event{Type: models.TransactionCreatedEvent, UserId: 1, TransactionId: int64(idx)})
+	}
+	received := 0
+	for range slow.Events {
+		received++
+	}
+	if received != subscriberBuffer {
+		t.Errorf("got %d events before the drop, want %d", received, subscriberBuffer)
+	}
+	if len(bus.subscribers) != 0 {
+		t.Errorf("%d subscribers are left", len(bus.subscribers))
+	}
+}
+
+func TestPublishSendsEventsToTheirUser(t *testing.T) {
+	bus := NewBus(10)
+	first, _, _ := bus.Subscribe(1, "")
+	defer first.Close()
+	second, _, _ := bus.Subscribe(2, "")
+	defer second.Close()
+
+	bus.Publish(models.Event{Type: models.TransactionCreatedEvent, UserId: 1, TransactionId: 1})
+	bus.Publish(models.Event{Type: models.TransactionCreatedEvent, UserId: 2, TransactionId: 2})
+	bus.Publish(models.Event{Type: models.TransactionCreatedEvent, TransactionId: 3})
+
+	received := func(subscription *Subscription) []int64 {
+		ids := make([]int64, 0)
+		for len(subscription.events) > 0 {
+			ids = append(ids, (<-subscription.Events).TransactionId)
+		}
+		return ids
+	}
+	if got := received(first); !sameIds(got, []int64{1, 3}) {
+		t.Errorf("user 1 got transactions %v, want [1 3]", got)
+	}
+	if got := received(second); !sameIds(got, []int64{2, 3}) {
+		t.Errorf("user 2 got transactions %v, want [2 3]", got)
+	}
+
+	fresh, missed, _ := bus.Subscribe(2, "")
+	fresh.Close()
+	if len(missed) != 0 {
+		t.Errorf("a subscription without an id got %v", missed)
+	}
+	firstId := bus.history[0].Id
+	resumed, missed, _ := bus.Subscribe(2, firstId)
+	defer resumed.Close()
+	if got := transactionIds(missed); !sameIds(got, []int64{2, 3}) {
+		t.Errorf("user 2 missed transactions %v, want [2 3]", got)
+	}
+}
@@ -0,0 +1,29 @@
+package exporter
+
+import (
+	"encoding/csv"
+	"io"
+	"spendon/models"
+)
+
+type csvWriter struct {
+	writer *csv.Writer
+}
+
+func NewCsvWriter(writer io.Writer) (Writer, error) {
+	csvWriter := &csvWriter{writer: csv.NewWriter(writer)}
+	err := csvWriter.writer.Write(header)
+	if err != nil {
+		return nil, err
+	}
+	return csvWriter, nil
+}
+
+func (writer *csvWriter) Write(row *models.ExportRow) error {
+	return writer.writer.Write(fields(row))
+}
+
+func (writer *csvWriter) Close() error {
+	writer.writer.Flush()
+	return writer.writer.Error()
+}
@@ -0,0 +1,109 @@
+package exporter
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"spendon/models"
+	"strings"
+)
+
+// Writer writes export rows one at a time. Close flushes whatever the format buffers and must
+// be called once all rows are written.
+type Writer interface {
+	Write(row *models.ExportRow) error
+	Close() error
+}
+
+type Format struct {
+	ContentType string
+	Extension   string
+	New         func(writer io.Writer) (Writer, error)
+}
+
+var formats = map[string]Format{
+	"csv":    {ContentType: "text/csv; charset=utf-8", Extension: "csv", New: NewCsvWriter},
+	"ndjson": {ContentType: "application/x-ndjson", Extension: "ndjson", New: NewNdjsonWriter},
+	"xlsx":   {ContentType: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", Extension: "xlsx", New: NewXlsxWriter},
+}
+
+func Formats() []string {
+	names := make([]string, 0, len(formats))
+	for name := range formats {
+		names = append(names, name)
+	}
+	return names
+}
+
+func Lookup(name string) (Format, error) {
+	if name == "" {
+		name = "csv"
+	}
+	format, ok := formats[strings.ToLower(name)]
+	if !ok {
+		return Format{}, fmt.Errorf("export format %q is not supported", name)
+	}
+	return format, nil
+}
+
+var currencySymbols = map[string]string{
+	"USD": "$",
+	"EUR": "€",
+	"GBP": "£",
+	"UAH": "₴",
+	"PLN": "zł",
+	"JPY": "¥",
+	"CHF": "CHF",
+}
+
+// FormatAmount writes amount with two decimals and a thousands separator, prefixed with the
+// currency symbol when one is known and followed by the currency code otherwise.
+func FormatAmount(amount float32, currency string) string {
+	cents := int64(math.Round(math.Abs(float64(amount)) * 100))
+	digits := fmt.Sprint(cents / 100)
+	grouped := make([]byte, 0, len(digits)+len(digits)/3)
+	for idx := range digits {
+		if idx > 0 && (len(digits)-idx)%3 == 0 {
+			grouped = append(grouped, ',')
+		}
+		grouped = append(grouped, digits[idx])
+	}
+	value := fmt.Sprintf("%s.%02d", grouped, cents%100)
+	if amount < 0 && cents != 0 {
+		value = "-" + value
+	}
+	if symbol, ok := currencySymbols[strings.ToUpper(currency)]; ok {
+		return symbol + value
+	}
+	if currency != "" {
+		return value + " " + currency
+	}
+	return value
+}
+
+// NewRow builds the export row of transaction, resolving the category name from categories.
+func NewRow(transaction *models.Transaction, categories map[int32]string, currency string) *models.ExportRow {
+	return &models.ExportRow{
+		Id:              transaction.Id,
+		SpentAt:         transaction.SpentAt,
+		Category:        categories[transaction.CategoryId],
+		Amount:          transaction.Amount,
+		Currency:        currency,
+		FormattedAmount: FormatAmount(transaction.Amount, currency),
+		Note:            transaction.Note,
+	}
+}
+
+var header = []string{"Id", "Date", "Category", "Amount", "Currency", "Formatted amount", "Note"}
+
+func fields(row *models.ExportRow) []string {
+	return []string{
+		fmt.Sprint(row.Id),
+		row.SpentAt,
+		row.Category,
+		fmt.Sprintf("%.2f", row.Amount),
+		row.Currency,
+		row.FormattedAmount,
+		row.Note,
+	}
+}
@@ -0,0 +1,186 @@
+package exporter
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"spendon/models"
+	"strings"
+	"unicode"
+)
+
+const (
+	defaultAssetAccount   = "Assets:SpendOn"
+	defaultExpenseAccount = "Expenses:{category}"
+	defaultCurrency       = "USD"
+	// beancountOpenDate opens the accounts before any transaction a user could have entered.
+	beancountOpenDate = "1970-01-01"
+)
+
+// accountRoots are the top level accounts beancount allows; ledger is less strict but reads them too.
+var accountRoots = []string{"Assets", "Liabilities", "Equity", "Income", "Expenses"}
+
+// journalWriter writes transactions as balanced two-posting entries: the category expense
+// account receives the amount and the asset account gives it. Income is negative spending in
+// this app, so it ends up as a negative expense posting, which both dialects accept.
+type journalWriter struct {
+	writer   io.Writer
+	dialect  string
+	naming   models.JournalNaming
+	accounts map[string]string
+}
+
+// NewJournalWriter starts a beancount or ledger journal. All accounts are declared up front,
+// so the journal stays valid for beancount no matter which categories the rows use.
+func NewJournalWriter(writer io.Writer, dialect string, naming models.JournalNaming, categories models.Categories) (Writer, error) {
+	dialect = strings.ToLower(dialect)
+	if dialect == "" {
+		dialect = models.JournalBeancount
+	}
+	if dialect != models.JournalBeancount && dialect != models.JournalLedger {
+		return nil, &models.FieldError{Field: "Dialect", Message: fmt.Sprintf("journal dialect %q is not supported", dialect)}
+	}
+	if naming.AssetAccount == "" {
+		naming.AssetAccount = defaultAssetAccount
+	}
+	if naming.ExpenseAccount == "" {
+		naming.ExpenseAccount = defaultExpenseAccount
+	}
+	if naming.Currency == "" {
+		naming.Currency = defaultCurrency
+	}
+	naming.Currency = strings.ToUpper(naming.Currency)
+	naming.AssetAccount = accountName(naming.AssetAccount)
+	if !hasAccountRoot(naming.AssetAccount) {
+		return nil, &models.FieldError{Field: "Naming.AssetAccount", Message: "must start with one of " + strings.Join(accountRoots, ", ")}
+	}
+	if !hasAccountRoot(accountName(naming.ExpenseAccount)) {
+		return nil, &models.FieldError{Field: "Naming.ExpenseAccount", Message: "must start with one of " + strings.Join(accountRoots, ", ")}
+	}
+	journal := &journalWriter{writer: writer, dialect: dialect, naming: naming, accounts: make(map[string]string, len(categories))}
+	for _, category := range categories {
+		journal.accounts[category.Name] = journal.expenseAccount(category.Name)
+	}
+	return journal, journal.writeHeader(categories)
+}
+
+func (journal *journalWriter) writeHeader(categories models.Categories) error {
+	accounts := []string{journal.naming.AssetAccount, journal.expenseAccount("")}
+	for _, category := range categories {
+		accounts = append(accounts, journal.accounts[category.Name])
+	}
+	lines := make([]string, 0, len(accounts)+2)
+	if journal.dialect == models.JournalBeancount {
+		lines = append(lines, fmt.Sprintf("option \"operating_currency\" \"%s\"", journal.naming.Currency), "")
+		for _, account := range unique(accounts) {
+			lines = append(lines, fmt.Sprintf("%s open %s %s", beancountOpenDate, account, journal.naming.Currency))
+		}
+	} else {
+		lines = append(lines, fmt.Sprintf("commodity %s", journal.naming.Currency), "")
+		for _, account := range unique(accounts) {
+			lines = append(lines, "account "+account)
+		}
+	}
+	_, err := io.WriteString(journal.writer, strings.Join(lines, "\n")+"\n")
+	return err
+}
+
+func (journal *journalWriter) Write(row *models.ExportRow) error {
+	account, ok := journal.accounts[row.Category]
+	if !ok {
+		account = journal.expenseAccount("")
+	}
+	date := row.SpentAt
+	if len(date) > 10 {
+		date = date[:10]
+	}
+	// Both postings come from the same rounded value, so every entry balances exactly.
+	rounded := math.Round(float64(row.Amount)*100) / 100
+	amount := fmt.Sprintf("%.2f", rounded)
+	negated := fmt.Sprintf("%.2f", 0-rounded)
+	var entry string
+	if journal.dialect == models.JournalBeancount {
+		entry = fmt.Sprintf("\n%s * %s\n  id: \"%d\"\n  %s  %s %s\n  %s  %s %s\n",
+			date, beancountString(row.Note), row.Id,
+			account, amount, journal.naming.Currency,
+			journal.naming.AssetAccount, negated, journal.naming.Currency)
+	} else {
+		entry = fmt.Sprintf("\n%s %s\n    ; id: %d\n    %s  %s %s\n    %s  %s %s\n",
+			strings.ReplaceAll(date, "-", "/"), ledgerPayee(row.Note), row.Id,
+			account, amount, journal.naming.Currency,
+			journal.naming.AssetAccount, negated, journal.naming.Currency)
+	}
+	_, err := io.WriteString(journal.writer, entry)
+	return err
+}
+
+func (journal *journalWriter) Close() error {
+	return nil
+}
+
+func (journal *journalWriter) expenseAccount(category string) string {
+	if category == "" {
+		category = "Uncategorized"
+	}
+	return accountName(strings.ReplaceAll(journal.naming.ExpenseAccount, "{category}", category))
+}
+
+// accountName makes every component of a colon separated account valid for both dialects:
+// it starts with an upper case letter and holds only letters, digits and dashes.
+func accountName(name string) string {
+	components := strings.Split(name, ":")
+	for idx, component := range components {
+		words := strings.FieldsFunc(component, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+		for wordIdx, word := range words {
+			runes := []rune(word)
+			runes[0] = unicode.ToUpper(runes[0])
+			words[wordIdx] = string(runes)
+		}
+		component = strings.Join(words, "-")
+		if component == "" {
+			component = "Other"
+		} else if first := []rune(component)[0]; !unicode.IsUpper(first) && !unicode.IsDigit(first) {
+			component = "X" + component
+		}
+		components[idx] = component
+	}
+	return strings.Join(components, ":")
+}
+
+func hasAccountRoot(account string) bool {
+	root := strings.Split(account, ":")[0]
+	for _, allowed := range accountRoots {
+		if root == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+func beancountString(value string) string {
+	value = strings.ReplaceAll(value, "\\", "\\\\")
+	return "\"" + strings.ReplaceAll(strings.Join(strings.Fields(value), " "), "\"", "\\\"") + "\""
+}
+
+// ledgerPayee keeps the payee on one line; a semicolon would start a comment.
+func ledgerPayee(value string) string {
+	value = strings.Join(strings.Fields(strings.ReplaceAll(value, ";", ",")), " ")
+	if value == "" {
+		return "Transaction"
+	}
+	return value
+}
+
+func unique(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			result = append(result, value)
+		}
+	}
+	return result
+}
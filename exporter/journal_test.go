@@ -0,0 +1,171 @@
+package exporter
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"spendon/models"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+var journalCategories = models.Categories{
+	{Id: 1, Name: "Food"},
+	{Id: 2, Name: "Rent & utilities"},
+	{Id: 3, Name: "salary"},
+}
+
+var journalRows = []models.ExportRow{
+	{Id: 1, SpentAt: "2023-01-05T10:00:00", Category: "Food", Amount: 12.345, Note: "Lunch; with \"friends\""},
+	{Id: 2, SpentAt: "2023-01-06T00:00:00", Category: "Rent & utilities", Amount: 800.1},
+	{Id: 3, SpentAt: "2023-01-07T09:30:00", Category: "salary", Amount: -2500.995, Note: "January"},
+	{Id: 4, SpentAt: "2023-01-08", Category: "Deleted category", Amount: 0.005},
+}
+
+// journalEntry is a parsed transaction of a journal, with its postings in cents.
+type journalEntry struct {
+	header   string
+	accounts []string
+	postings []int64
+}
+
+func writeJournal(t *testing.T, dialect string, naming models.JournalNaming) string {
+	t.Helper()
+	buffer := &bytes.Buffer{}
+	writer, err := NewJournalWriter(buffer, dialect, naming, journalCategories)
+	if err != nil {
+		t.Fatalf("NewJournalWriter: %v", err)
+	}
+	for idx := range journalRows {
+		err = writer.Write(&journalRows[idx])
+		if err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+	err = writer.Close()
+	if err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	return buffer.String()
+}
+
+// parseJournal reads back the declared accounts and the entries of a journal written by journalWriter.
+func parseJournal(t *testing.T, dialect, journal string) (map[string]bool, []journalEntry) {
+	t.Helper()
+	declared := make(map[string]bool)
+	entries := make([]journalEntry, 0)
+	for _, line := range strings.Split(journal, "\n") {
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 0:
+		case dialect == models.JournalBeancount && len(fields) == 4 && fields[1] == "open":
+			declared[fields[2]] = true
+		case dialect == models.JournalLedger && fields[0] == "account":
+			declared[fields[1]] = true
+		case fields[0] == "option" || fields[0] == "commodity":
+		case !strings.HasPrefix(line, " "):
+			entries = append(entries, journalEntry{header: line})
+		case fields[0] == "id:" || fields[0] == ";":
+		default:
+			if len(entries) == 0 {
+				t.Fatalf("posting %q comes before any entry", line)
+			}
+			if len(fields) != 3 {
+				t.Fatalf("posting %q does not have an account, amount and currency", line)
+			}
+			amount, err := strconv.ParseFloat(fields[1], 64)
+			if err != nil {
+				t.Fatalf("posting %q has a bad amount: %v", line, err)
+			}
+			entry := &entries[len(entries)-1]
+			entry.accounts = append(entry.accounts, fields[0])
+			entry.postings = append(entry.postings, int64(math.Round(amount*100)))
+		}
+	}
+	return declared, entries
+}
+
+func TestJournalRoundTripBalances(t *testing.T) {
+	for _, dialect := range []string{models.JournalBeancount, models.JournalLedger} {
+		t.Run(dialect, func(t *testing.T) {
+			journal := writeJournal(t, dialect, models.JournalNaming{Currency: "uah"})
+			declared, entries := parseJournal(t, dialect, journal)
+			if len(entries) != len(journalRows) {
+				t.Fatalf("got %d entries, want %d:\n%s", len(entries), len(journalRows), journal)
+			}
+			for idx, entry := range entries {
+				if len(entry.postings) != 2 {
+					t.Errorf("entry %q has %d postings, want 2", entry.header, len(entry.postings))
+				}
+				var sum int64
+				for _, posting := range entry.postings {
+					sum += posting
+				}
+				if sum != 0 {
+					t.Errorf("entry %q does not balance: postings sum to %d cents", entry.header, sum)
+				}
+				want := int64(math.Round(float64(journalRows[idx].Amount) * 100))
+				if entry.postings[0] != want {
+					t.Errorf("entry %q posts %d cents, want %d", entry.header, entry.postings[0], want)
+				}
+				for _, account := range entry.accounts {
+					if !declared[account] {
+						t.Errorf("entry %q uses undeclared account %s", entry.header, account)
+					}
+					if !hasAccountRoot(account) {
+						t.Errorf("entry %q uses account %s outside the allowed roots", entry.header, account)
+					}
+				}
+			}
+			if !strings.Contains(journal, " UAH\n") {
+				t.Errorf("journal does not use the upper cased currency:\n%s", journal)
+			}
+		})
+	}
+}
+
+func TestJournalAccountNames(t *testing.T) {
+	naming := models.JournalNaming{AssetAccount: "assets:my bank", ExpenseAccount: "Expenses:Home:{category}"}
+	declared, entries := parseJournal(t, models.JournalBeancount, writeJournal(t, models.JournalBeancount, naming))
+	for _, account := range []string{"Assets:My-Bank", "Expenses:Home:Food", "Expenses:Home:Rent-Utilities", "Expenses:Home:Salary", "Expenses:Home:Uncategorized"} {
+		if !declared[account] {
+			t.Errorf("account %s is not declared, got %v", account, declared)
+		}
+	}
+	if got := entries[3].accounts[0]; got != "Expenses:Home:Uncategorized" {
+		t.Errorf("unknown category posts to %s, want Expenses:Home:Uncategorized", got)
+	}
+}
+
+func TestJournalRejectsBadNaming(t *testing.T) {
+	tests := []struct {
+		name    string
+		dialect string
+		naming  models.JournalNaming
+		field   string
+	}{
+		{"dialect", "hledger-csv", models.JournalNaming{}, "Dialect"},
+		{"asset root", models.JournalBeancount, models.JournalNaming{AssetAccount: "Wallet:Cash"}, "Naming.AssetAccount"},
+		{"expense root", models.JournalLedger, models.JournalNaming{ExpenseAccount: "{category}"}, "Naming.ExpenseAccount"},
+		{"expense root prefix", models.JournalBeancount, models.JournalNaming{ExpenseAccount: "Spending:{category}"}, "Naming.ExpenseAccount"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := NewJournalWriter(&bytes.Buffer{}, test.dialect, test.naming, journalCategories)
+			var fieldError *models.FieldError
+			if !errors.As(err, &fieldError) {
+				t.Fatalf("got error %v, want a field error", err)
+			}
+			if fieldError.Field != test.field {
+				t.Errorf("got field %s, want %s", fieldError.Field, test.field)
+			}
+		})
+	}
+	for _, root := range accountRoots {
+		_, err := NewJournalWriter(&bytes.Buffer{}, models.JournalLedger, models.JournalNaming{AssetAccount: root + ":Cash", ExpenseAccount: root + ":{category}"}, journalCategories)
+		if err != nil {
+			t.Errorf("root %s was rejected: %v", root, err)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package exporter
+
+import (
+	"encoding/json"
+	"io"
+	"spendon/models"
+)
+
+type ndjsonWriter struct {
+	encoder *json.Encoder
+}
+
+// NewNdjsonWriter writes every row as a JSON object on its own line.
+func NewNdjsonWriter(writer io.Writer) (Writer, error) {
+	return &ndjsonWriter{encoder: json.NewEncoder(writer)}, nil
+}
+
+func (writer *ndjsonWriter) Write(row *models.ExportRow) error {
+	return writer.encoder.Encode(row)
+}
+
+func (writer *ndjsonWriter) Close() error {
+	return nil
+}
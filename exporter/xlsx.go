@@ -0,0 +1,118 @@
+package exporter
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"spendon/models"
+	"strings"
+)
+
+var xlsxParts = []struct {
+	name    string
+	content string
+}{
+	{"[Content_Types].xml", xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` +
+		`</Types>`},
+	{"_rels/.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`},
+	{"xl/workbook.xml", xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="Transactions" sheetId="1" r:id="rId1"/></sheets></workbook>`},
+	{"xl/_rels/workbook.xml.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>` +
+		`</Relationships>`},
+	// Style 1 shows amounts with two decimals.
+	{"xl/styles.xml", xml.Header + `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+		`<fonts count="1"><font/></fonts><fills count="1"><fill/></fills><borders count="1"><border/></borders>` +
+		`<cellStyleXfs count="1"><xf/></cellStyleXfs>` +
+		`<cellXfs count="2"><xf/><xf numFmtId="4" applyNumberFormat="1"/></cellXfs>` +
+		`</styleSheet>`},
+}
+
+// xlsxWriter streams a single worksheet into the zip archive. Strings are written inline, so
+// no shared string table has to be built up in memory.
+type xlsxWriter struct {
+	archive *zip.Writer
+	sheet   *bufio.Writer
+	row     int
+}
+
+func NewXlsxWriter(writer io.Writer) (Writer, error) {
+	archive := zip.NewWriter(writer)
+	for _, part := range xlsxParts {
+		partWriter, err := archive.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		_, err = io.WriteString(partWriter, part.content)
+		if err != nil {
+			return nil, err
+		}
+	}
+	sheetWriter, err := archive.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	xlsx := &xlsxWriter{archive: archive, sheet: bufio.NewWriter(sheetWriter)}
+	_, err = xlsx.sheet.WriteString(xml.Header + `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	if err != nil {
+		return nil, err
+	}
+	cells := make([]string, 0, len(header))
+	for _, title := range header {
+		cells = append(cells, xlsxString(title))
+	}
+	return xlsx, xlsx.writeRow(cells)
+}
+
+func (writer *xlsxWriter) Write(row *models.ExportRow) error {
+	return writer.writeRow([]string{
+		xlsxNumber(fmt.Sprint(row.Id), 0),
+		xlsxString(row.SpentAt),
+		xlsxString(row.Category),
+		xlsxNumber(fmt.Sprintf("%.2f", row.Amount), 1),
+		xlsxString(row.Currency),
+		xlsxString(row.FormattedAmount),
+		xlsxString(row.Note),
+	})
+}
+
+func (writer *xlsxWriter) Close() error {
+	_, err := writer.sheet.WriteString(`</sheetData></worksheet>`)
+	if err != nil {
+		return err
+	}
+	err = writer.sheet.Flush()
+	if err != nil {
+		return err
+	}
+	return writer.archive.Close()
+}
+
+func (writer *xlsxWriter) writeRow(cells []string) error {
+	writer.row++
+	_, err := fmt.Fprintf(writer.sheet, `<row r="%d">%s</row>`, writer.row, strings.Join(cells, ""))
+	return err
+}
+
+func xlsxString(value string) string {
+	builder := strings.Builder{}
+	_ = xml.EscapeText(&builder, []byte(value))
+	return `<c t="inlineStr"><is><t xml:space="preserve">` + builder.String() + `</t></is></c>`
+}
+
+func xlsxNumber(value string, style int) string {
+	if style == 0 {
+		return `<c><v>` + value + `</v></c>`
+	}
+	return fmt.Sprintf(`<c s="%d"><v>%s</v></c>`, style, value)
+}
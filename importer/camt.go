@@ -0,0 +1,169 @@
+package importer
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"spendon/models"
+	"strings"
+	"time"
+)
+
+type camtAmount struct {
+	Value    string `xml:",chardata"`
+	Currency string `xml:"Ccy,attr"`
+}
+
+type camtDate struct {
+	Date     string `xml:"Dt"`
+	DateTime string `xml:"DtTm"`
+}
+
+type camtTransactionDetails struct {
+	EndToEndId    string   `xml:"Refs>EndToEndId"`
+	Unstructured  []string `xml:"RmtInf>Ustrd"`
+	CreditorName  string   `xml:"RltdPties>Cdtr>Nm"`
+	DebtorName    string   `xml:"RltdPties>Dbtr>Nm"`
+	CreditorPtyNm string   `xml:"RltdPties>Cdtr>Pty>Nm"`
+	DebtorPtyNm   string   `xml:"RltdPties>Dbtr>Pty>Nm"`
+}
+
+type camtEntry struct {
+	Reference         string                   `xml:"NtryRef"`
+	Amount            camtAmount               `xml:"Amt"`
+	CreditDebit       string                   `xml:"CdtDbtInd"`
+	Status            string                   `xml:"Sts"`
+	BookingDate       camtDate                 `xml:"BookgDt"`
+	ValueDate         camtDate                 `xml:"ValDt"`
+	ServicerReference string                   `xml:"AcctSvcrRef"`
+	Details           []camtTransactionDetails `xml:"NtryDtls>TxDtls"`
+	AdditionalInfo    string                   `xml:"AddtlNtryInf"`
+}
+
+type camtAccount struct {
+	Iban  string `xml:"Id>IBAN"`
+	Other string `xml:"Id>Othr>Id"`
+}
+
+// ParseCamt053 streams ISO 20022 camt.053 bank-to-customer statements entry by entry, so
+// files with many statements are never loaded as a whole. Debit entries are spending.
+func ParseCamt053(reader io.Reader, mapping *models.ImportMapping) (*models.ImportPreview, error) {
+	preview := &models.ImportPreview{Rows: make([]models.ImportRow, 0)}
+	decoder := xml.NewDecoder(reader)
+	account := ""
+	occurrences := make(map[string]int)
+	line := 0
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return preview, err
+		}
+		element, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch element.Name.Local {
+		case "Stmt":
+			account = ""
+		case "Acct":
+			camtAccount := camtAccount{}
+			err = decoder.DecodeElement(&camtAccount, &element)
+			if err != nil {
+				return preview, err
+			}
+			account = camtAccount.Iban
+			if account == "" {
+				account = camtAccount.Other
+			}
+		case "Ntry":
+			entry := camtEntry{}
+			err = decoder.DecodeElement(&entry, &element)
+			if err != nil {
+				return preview, err
+			}
+			line++
+			preview.Add(camtRow(line, account, &entry, occurrences))
+		}
+	}
+	return preview, nil
+}
+
+func camtRow(line int, account string, entry *camtEntry, occurrences map[string]int) models.ImportRow {
+	row := models.ImportRow{Line: line, Currency: entry.Amount.Currency}
+	spentAt, err := entry.BookingDate.parse()
+	if err != nil {
+		spentAt, err = entry.ValueDate.parse()
+	}
+	if err != nil {
+		row.Error = err.Error()
+		return row
+	}
+	amount, err := ParseAmount(entry.Amount.Value, ".")
+	if err != nil {
+		row.Error = err.Error()
+		return row
+	}
+	debit := entry.CreditDebit == "DBIT"
+	if !debit {
+		amount = -amount
+	}
+	notes := make([]string, 0)
+	reference := entry.ServicerReference
+	if reference == "" {
+		reference = entry.Reference
+	}
+	for _, details := range entry.Details {
+		counterparty := firstNonEmpty(details.CreditorName, details.CreditorPtyNm)
+		if !debit {
+			counterparty = firstNonEmpty(details.DebtorName, details.DebtorPtyNm)
+		}
+		notes = append(notes, joinNote(counterparty, strings.Join(details.Unstructured, " ")))
+		if reference == "" && details.EndToEndId != "" && details.EndToEndId != "NOTPROVIDED" {
+			reference = details.EndToEndId
+		}
+	}
+	if len(notes) == 0 {
+		notes = append(notes, entry.AdditionalInfo)
+	}
+	row.Transaction = models.Transaction{
+		Amount:  amount,
+		SpentAt: spentAt.Format(models.SpentAtLayout),
+		Note:    strings.TrimSpace(strings.Join(notes, "; ")),
+	}
+	if reference != "" {
+		row.Transaction.ExternalId = "camt:" + account + ":" + reference
+	} else {
+		key := strings.Join([]string{account, spentAt.Format(models.SpentAtLayout), entry.Amount.Value, entry.CreditDebit, row.Transaction.Note}, "|")
+		occurrences[key]++
+		row.Transaction.ExternalId = StableId("camt", key, fmt.Sprint(occurrences[key]))
+	}
+	return row
+}
+
+func (date *camtDate) parse() (time.Time, error) {
+	if date.DateTime != "" {
+		for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05"} {
+			if parsed, err := time.Parse(layout, strings.TrimSpace(date.DateTime)); err == nil {
+				return parsed.UTC(), nil
+			}
+		}
+	}
+	if date.Date != "" {
+		if parsed, err := time.Parse("2006-01-02", strings.TrimSpace(date.Date)); err == nil {
+			return parsed, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("entry has no booking or value date")
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if strings.TrimSpace(value) != "" {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,52 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseCamt053(t *testing.T) {
+	preview := parseFixture(t, ParseCamt053, "camt053_statement.xml", nil)
+	checkRows(t, preview, []wantRow{
+		{Amount: 42.5, SpentAt: "2024-03-04T00:00:00", Note: "Green Grocer - Weekly groceries", ExternalId: "camt:DE89370400440532013000:20240304-0001"},
+		{Amount: -2500, SpentAt: "2024-03-05T00:00:00", Note: "Salary March", ExternalId: "camt:DE89370400440532013000:20240305-0002"},
+		{Amount: 19.99, SpentAt: "2024-03-07T10:15:00", Note: "Streaming subscription"},
+	})
+	// Each statement has its own account and currency.
+	for idx, currency := range []string{"EUR", "EUR", "USD"} {
+		if row := preview.Rows[idx]; row.Currency != currency || row.Line != idx+1 {
+			t.Errorf("row %d has currency %s and line %d, want %s and %d", idx, row.Currency, row.Line, currency, idx+1)
+		}
+	}
+	if id := preview.Rows[2].Transaction.ExternalId; !strings.HasPrefix(id, "camt:") {
+		t.Errorf("an entry without a reference got ExternalId %q", id)
+	}
+	checkStableIds(t, ParseCamt053, "camt053_statement.xml", preview)
+}
+
+func TestParseCamt053BatchEntry(t *testing.T) {
+	statement := `<Document><BkToCstmrStmt><Stmt>
+		<Acct><Id><IBAN>UA213223130000026007233566001</IBAN></Id></Acct>
+		<Ntry>
+			<Amt Ccy="UAH">300.00</Amt><CdtDbtInd>CRDT</CdtDbtInd>
+			<ValDt><Dt>2024-04-02</Dt></ValDt>
+			<NtryDtls>
+				<TxDtls><Refs><EndToEndId>E2E-7</EndToEndId></Refs>
+					<RltdPties><Dbtr><Nm>Olena</Nm></Dbtr><Cdtr><Nm>Me</Nm></Cdtr></RltdPties>
+					<RmtInf><Ustrd>Dinner</Ustrd><Ustrd>split</Ustrd></RmtInf></TxDtls>
+				<TxDtls><RltdPties><Dbtr><Pty><Nm>Taras</Nm></Pty></Dbtr></RltdPties></TxDtls>
+			</NtryDtls>
+		</Ntry>
+		<Ntry><Amt Ccy="UAH">10.00</Amt><CdtDbtInd>DBIT</CdtDbtInd></Ntry>
+		<Ntry><Amt Ccy="UAH">ten</Amt><CdtDbtInd>DBIT</CdtDbtInd><BookgDt><Dt>2024-04-03</Dt></BookgDt></Ntry>
+	</Stmt></BkToCstmrStmt></Document>`
+	preview, err := ParseCamt053(strings.NewReader(statement), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkRows(t, preview, []wantRow{
+		{Amount: -300, SpentAt: "2024-04-02T00:00:00", Note: "Olena - Dinner split; Taras", ExternalId: "camt:UA213223130000026007233566001:E2E-7"},
+		{Error: true},
+		{Error: true},
+	})
+}
@@ -0,0 +1,132 @@
+package importer
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"spendon/models"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+func ParseCsv(reader io.Reader, mapping *models.ImportMapping) (*models.ImportPreview, error) {
+	decodedReader, err := decode(reader, mapping.Encoding)
+	if err != nil {
+		return nil, err
+	}
+	csvReader := csv.NewReader(decodedReader)
+	csvReader.FieldsPerRecord = -1
+	csvReader.LazyQuotes = true
+	if mapping.Delimiter != "" {
+		delimiter, _ := utf8.DecodeRuneInString(strings.Replace(mapping.Delimiter, "\\t", "\t", 1))
+		csvReader.Comma = delimiter
+	}
+	if _, ok := mapping.Columns[models.SpentAtColumn]; !ok {
+		return nil, fmt.Errorf("%s column is not mapped", models.SpentAtColumn)
+	}
+	preview := &models.ImportPreview{Rows: make([]models.ImportRow, 0)}
+	for line := 1; ; line++ {
+		record, err := csvReader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return preview, err
+		}
+		if line <= mapping.SkipRows || isEmptyRecord(record) {
+			continue
+		}
+		preview.Add(parseCsvRecord(line, record, mapping))
+	}
+	return preview, nil
+}
+
+func parseCsvRecord(line int, record []string, mapping *models.ImportMapping) models.ImportRow {
+	row := models.ImportRow{Line: line}
+	column := func(name string) string {
+		idx, ok := mapping.Columns[name]
+		if !ok || idx < 0 || idx >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(strings.TrimPrefix(record[idx], "\uFEFF"))
+	}
+	dateFormat := mapping.DateFormat
+	if dateFormat == "" {
+		dateFormat = "2006-01-02"
+	}
+	spentAt, err := time.Parse(dateFormat, column(models.SpentAtColumn))
+	if err != nil {
+		row.Error = fmt.Sprintf("date %q does not match format %q", column(models.SpentAtColumn), dateFormat)
+		return row
+	}
+	row.Transaction.SpentAt = spentAt.Format(models.SpentAtLayout)
+	row.Transaction.Amount, err = csvAmount(column, mapping)
+	if err != nil {
+		row.Error = err.Error()
+		return row
+	}
+	row.Transaction.Note = column(models.NoteColumn)
+	row.Category = column(models.CategoryColumn)
+	return row
+}
+
+func csvAmount(column func(string) string, mapping *models.ImportMapping) (float32, error) {
+	if mapping.SignConvention == models.DebitCredit {
+		var debit, credit float32
+		var err error
+		if value := column(models.DebitColumn); value != "" {
+			debit, err = ParseAmount(value, mapping.DecimalSeparator)
+			if err != nil {
+				return 0, err
+			}
+		}
+		if value := column(models.CreditColumn); value != "" {
+			credit, err = ParseAmount(value, mapping.DecimalSeparator)
+			if err != nil {
+				return 0, err
+			}
+		}
+		return abs(debit) - abs(credit), nil
+	}
+	amount, err := ParseAmount(column(models.AmountColumn), mapping.DecimalSeparator)
+	if err != nil {
+		return 0, err
+	}
+	if mapping.SignConvention == models.InvertSign {
+		return -amount, nil
+	}
+	return amount, nil
+}
+
+func decode(reader io.Reader, encoding string) (io.Reader, error) {
+	switch strings.ToLower(strings.ReplaceAll(encoding, "-", "")) {
+	case "", "utf8":
+		return bufio.NewReader(reader), nil
+	case "windows1251", "cp1251":
+		return charmap.Windows1251.NewDecoder().Reader(reader), nil
+	case "koi8u":
+		return charmap.KOI8U.NewDecoder().Reader(reader), nil
+	default:
+		return nil, fmt.Errorf("encoding %q is not supported", encoding)
+	}
+}
+
+func isEmptyRecord(record []string) bool {
+	for _, field := range record {
+		if strings.TrimSpace(field) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func abs(value float32) float32 {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
@@ -0,0 +1,131 @@
+package importer
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"spendon/models"
+	"strconv"
+	"strings"
+)
+
+// Parser reads a statement into preview rows. Rows that could not be parsed carry an error
+// instead of failing the whole statement.
+type Parser func(reader io.Reader, mapping *models.ImportMapping) (*models.ImportPreview, error)
+
+var parsers = map[string]Parser{
+	"csv":        ParseCsv,
+	"ofx":        ParseOfx,
+	"qfx":        ParseOfx,
+	"qif":        ParseQif,
+	"monobank":   ParseMonobank,
+	"privatbank": ParsePrivatbank,
+	"camt053":    ParseCamt053,
+	"mt940":      ParseMt940,
+}
+
+func Formats() []string {
+	formats := make([]string, 0, len(parsers))
+	for format := range parsers {
+		formats = append(formats, format)
+	}
+	return formats
+}
+
+func Parse(format string, reader io.Reader, mapping *models.ImportMapping) (*models.ImportPreview, error) {
+	parser, ok := parsers[strings.ToLower(format)]
+	if !ok {
+		return nil, fmt.Errorf("import format %q is not supported", format)
+	}
+	return parser(reader, mapping)
+}
+
+// ResolveCategories sets the category of every valid row from its category text, which may be
+// a category id or a name. Rows without a known category get defaultCategoryId.
+func ResolveCategories(preview *models.ImportPreview, categories models.Categories, defaultCategoryId int32) {
+	byName := make(map[string]int32, len(categories))
+	byId := make(map[int32]bool, len(categories))
+	for _, category := range categories {
+		byName[strings.ToLower(category.Name)] = category.Id
+		byId[category.Id] = true
+	}
+	for idx := range preview.Rows {
+		row := &preview.Rows[idx]
+		if row.Error != "" || row.Transaction.CategoryId != 0 {
+			continue
+		}
+		category := strings.TrimSpace(row.Category)
+		if id, err := strconv.ParseInt(category, 10, 32); err == nil && byId[int32(id)] {
+			row.Transaction.CategoryId = int32(id)
+		} else if id, ok := byName[strings.ToLower(category)]; ok {
+			row.Transaction.CategoryId = id
+		} else if defaultCategoryId != 0 {
+			row.Transaction.CategoryId = defaultCategoryId
+		} else {
+			row.Error = fmt.Sprintf("unknown category %q", category)
+			preview.Valid--
+			preview.Errors++
+		}
+	}
+}
+
+// CheckCurrency marks valid rows whose statement currency differs from the user's currency as
+// errors, since amounts are stored without conversion. Rows without a currency are left alone.
+func CheckCurrency(preview *models.ImportPreview, currency string) {
+	if currency == "" {
+		return
+	}
+	for idx := range preview.Rows {
+		row := &preview.Rows[idx]
+		if row.Error != "" || row.Currency == "" || strings.EqualFold(row.Currency, currency) {
+			continue
+		}
+		row.Error = fmt.Sprintf("currency %s does not match account currency %s", row.Currency, currency)
+		preview.Valid--
+		preview.Errors++
+	}
+}
+
+// ParseAmount parses amounts written with either decimal separator, thousands separators,
+// spaces and currency signs.
+func ParseAmount(value, decimalSeparator string) (float32, error) {
+	if decimalSeparator == "," {
+		value = strings.ReplaceAll(value, ".", "")
+		value = strings.ReplaceAll(value, ",", ".")
+	} else {
+		value = strings.ReplaceAll(value, ",", "")
+	}
+	cleaned := strings.Builder{}
+	for _, symbol := range value {
+		if (symbol >= '0' && symbol <= '9') || symbol == '.' || symbol == '-' || symbol == '+' {
+			cleaned.WriteRune(symbol)
+		}
+	}
+	if cleaned.Len() == 0 {
+		return 0, fmt.Errorf("amount %q is empty", value)
+	}
+	amount, err := strconv.ParseFloat(cleaned.String(), 32)
+	if err != nil {
+		return 0, fmt.Errorf("amount %q is not a number", value)
+	}
+	return float32(amount), nil
+}
+
+// StableId builds an ExternalId for statements without transaction ids, so the same row
+// gets the same id each time the statement is imported.
+func StableId(prefix string, parts ...string) string {
+	hash := sha256.Sum256([]byte(strings.Join(parts, "\x1f")))
+	return fmt.Sprintf("%s:%x", prefix, hash[:16])
+}
+
+// joinNote combines payee and memo, skipping empty and repeated parts.
+func joinNote(payee, memo string) string {
+	payee, memo = strings.TrimSpace(payee), strings.TrimSpace(memo)
+	if payee == "" || strings.EqualFold(payee, memo) {
+		return memo
+	}
+	if memo == "" {
+		return payee
+	}
+	return payee + " - " + memo
+}
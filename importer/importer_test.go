@@ -0,0 +1,97 @@
+package importer
+
+import (
+	"os"
+	"path/filepath"
+	"spendon/models"
+	"testing"
+)
+
+// wantRow is what a test expects of a parsed row. An empty Error means the row is valid.
+type wantRow struct {
+	Amount     float32
+	SpentAt    string
+	Note       string
+	Category   string
+	ExternalId string
+	Error      bool
+}
+
+func parseFixture(t *testing.T, parser Parser, name string, mapping *models.ImportMapping) *models.ImportPreview {
+	t.Helper()
+	file, err := os.Open(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if mapping == nil {
+		mapping = &models.ImportMapping{}
+	}
+	preview, err := parser(file, mapping)
+	if err != nil {
+		t.Fatalf("parsing %s: %v", name, err)
+	}
+	return preview
+}
+
+// checkRows compares the rows with want. ExternalId is only compared when want has one.
+func checkRows(t *testing.T, preview *models.ImportPreview, want []wantRow) {
+	t.Helper()
+	if len(preview.Rows) != len(want) {
+		t.Fatalf("got %d rows, want %d: %+v", len(preview.Rows), len(want), preview.Rows)
+	}
+	errors := 0
+	for idx, row := range preview.Rows {
+		expected := want[idx]
+		if expected.Error {
+			errors++
+			if row.Error == "" {
+				t.Errorf("row %d parsed as %+v, want an error", idx, row.Transaction)
+			}
+			continue
+		}
+		if row.Error != "" {
+			t.Errorf("row %d failed: %s", idx, row.Error)
+			continue
+		}
+		transaction := row.Transaction
+		if transaction.Amount != expected.Amount || transaction.SpentAt != expected.SpentAt || transaction.Note != expected.Note {
+			t.Errorf("row %d is %v %s %q, want %v %s %q", idx,
+				transaction.Amount, transaction.SpentAt, transaction.Note,
+				expected.Amount, expected.SpentAt, expected.Note)
+		}
+		if row.Category != expected.Category {
+			t.Errorf("row %d has category %q, want %q", idx, row.Category, expected.Category)
+		}
+		if expected.ExternalId != "" && transaction.ExternalId != expected.ExternalId {
+			t.Errorf("row %d has ExternalId %q, want %q", idx, transaction.ExternalId, expected.ExternalId)
+		}
+	}
+	if preview.Errors != errors || preview.Valid != len(want)-errors {
+		t.Errorf("preview counts %d valid and %d errors, want %d and %d", preview.Valid, preview.Errors, len(want)-errors, errors)
+	}
+}
+
+// checkStableIds parses the fixture again and expects the same ExternalIds, all distinct, so a
+// repeated import of the statement is recognised as duplicates.
+func checkStableIds(t *testing.T, parser Parser, name string, first *models.ImportPreview) {
+	t.Helper()
+	second := parseFixture(t, parser, name, nil)
+	seen := make(map[string]bool)
+	for idx, row := range first.Rows {
+		if row.Error != "" {
+			continue
+		}
+		id := row.Transaction.ExternalId
+		if id == "" {
+			t.Errorf("row %d has no ExternalId", idx)
+		}
+		if seen[id] {
+			t.Errorf("row %d repeats ExternalId %q", idx, id)
+		}
+		seen[id] = true
+		if again := second.Rows[idx].Transaction.ExternalId; again != id {
+			t.Errorf("row %d got ExternalId %q, then %q", idx, id, again)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package importer
+
+import (
+	"strconv"
+	"strings"
+)
+
+// defaultMccCategories maps merchant category codes and code ranges to SpendOn category names.
+var defaultMccCategories = map[string]string{
+	"5411":      "Groccesies",
+	"5412":      "Groccesies",
+	"5422":      "Groccesies",
+	"5441":      "Groccesies",
+	"5451":      "Groccesies",
+	"5462":      "Groccesies",
+	"5499":      "Groccesies",
+	"5811-5814": "Cafes",
+	"5541-5542": "Fuel",
+	"5983":      "Fuel",
+	"5511-5599": "Car",
+	"7531-7549": "Car",
+	"4111-4131": "Transport",
+	"4121":      "Transport",
+	"4784":      "Transport",
+	"4011":      "Transport",
+	"3000-3299": "Transport",
+	"4511":      "Transport",
+	"5912":      "Medicine",
+	"8011-8099": "Medicine",
+	"5122":      "Medicine",
+	"5940-5941": "Sport",
+	"7941":      "Sport",
+	"7997":      "Sport",
+	"5815-5818": "Games",
+	"5945":      "Games",
+	"7994":      "Games",
+	"7991":      "Museums",
+	"7922":      "Museums",
+	"5200-5261": "Householding",
+	"5712-5722": "Householding",
+	"4814-4816": "Services",
+	"4899-4900": "Services",
+	"7299":      "Services",
+	"4829":      "Money Send",
+	"6010-6012": "Money Send",
+	"6538":      "Money Send",
+}
+
+var mccCategories = defaultMccCategories
+
+// ConfigureMcc replaces the MCC table. Keys are single codes or "from-to" ranges, values are
+// category names or ids. An empty table keeps the defaults.
+func ConfigureMcc(table map[string]string) {
+	if len(table) == 0 {
+		mccCategories = defaultMccCategories
+		return
+	}
+	mccCategories = table
+}
+
+// MccCategory returns the category for the code, preferring exact codes over the narrowest range.
+func MccCategory(mcc int) string {
+	if category, ok := mccCategories[strconv.Itoa(mcc)]; ok {
+		return category
+	}
+	matchedCategory, matchedWidth := "", -1
+	for codes, category := range mccCategories {
+		bounds := strings.SplitN(codes, "-", 2)
+		if len(bounds) != 2 {
+			continue
+		}
+		from, fromErr := strconv.Atoi(strings.TrimSpace(bounds[0]))
+		to, toErr := strconv.Atoi(strings.TrimSpace(bounds[1]))
+		if fromErr != nil || toErr != nil || mcc < from || mcc > to {
+			continue
+		}
+		if matchedWidth < 0 || to-from < matchedWidth {
+			matchedCategory, matchedWidth = category, to-from
+		}
+	}
+	return matchedCategory
+}
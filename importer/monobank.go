@@ -0,0 +1,71 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"spendon/models"
+	"time"
+)
+
+type monobankStatementItem struct {
+	Id          string
+	Time        int64
+	Description string
+	Mcc         int
+	Amount      int64
+	Comment     string
+}
+
+type monobankWebhook struct {
+	Type string
+	Data struct {
+		Account       string
+		StatementItem monobankStatementItem
+	}
+}
+
+// ParseMonobank reads the personal statement JSON returned by Monobank, an array of statement items
+// with amounts in minor units and spending below zero.
+func ParseMonobank(reader io.Reader, mapping *models.ImportMapping) (*models.ImportPreview, error) {
+	items := make([]monobankStatementItem, 0)
+	err := json.NewDecoder(reader).Decode(&items)
+	if err != nil {
+		return nil, err
+	}
+	preview := &models.ImportPreview{Rows: make([]models.ImportRow, 0, len(items))}
+	for idx, item := range items {
+		preview.Add(monobankRow(idx+1, &item))
+	}
+	return preview, nil
+}
+
+// ParseMonobankWebhook reads a push sent by Monobank for a new statement item.
+func ParseMonobankWebhook(reader io.Reader) (*models.ImportPreview, error) {
+	webhook := monobankWebhook{}
+	err := json.NewDecoder(reader).Decode(&webhook)
+	if err != nil {
+		return nil, err
+	}
+	if webhook.Type != "StatementItem" {
+		return nil, fmt.Errorf("monobank webhook type %q is not supported", webhook.Type)
+	}
+	preview := &models.ImportPreview{Rows: make([]models.ImportRow, 0, 1)}
+	preview.Add(monobankRow(1, &webhook.Data.StatementItem))
+	return preview, nil
+}
+
+func monobankRow(line int, item *monobankStatementItem) models.ImportRow {
+	row := models.ImportRow{Line: line, Category: MccCategory(item.Mcc)}
+	if item.Id == "" || item.Time == 0 {
+		row.Error = "statement item has no id or time"
+		return row
+	}
+	row.Transaction = models.Transaction{
+		Amount:     -float32(item.Amount) / 100,
+		SpentAt:    time.Unix(item.Time, 0).UTC().Format(models.SpentAtLayout),
+		Note:       joinNote(item.Description, item.Comment),
+		ExternalId: "monobank:" + item.Id,
+	}
+	return row
+}
@@ -0,0 +1,73 @@
+package importer
+
+import (
+	"io"
+	"spendon/models"
+	"strings"
+	"testing"
+)
+
+func TestParseMonobank(t *testing.T) {
+	preview := parseFixture(t, ParseMonobank, "monobank_statement.json", nil)
+	checkRows(t, preview, []wantRow{
+		{Amount: 1234.5, SpentAt: "2026-02-03T10:15:00", Note: "Сільпо", Category: "Groccesies", ExternalId: "monobank:ZuHWzqkKGVo="},
+		{Amount: 320, SpentAt: "2026-02-05T09:00:00", Note: "Пузата Хата - Обід", Category: "Cafes", ExternalId: "monobank:5qA2UEyz3NA="},
+		{Amount: -1500, SpentAt: "2026-02-11T08:53:20", Note: "Від: Олена - На подарунок", Category: "Money Send", ExternalId: "monobank:Kq7Vd1lR9bY="},
+	})
+	checkStableIds(t, ParseMonobank, "monobank_statement.json", preview)
+}
+
+func TestParseMonobankRejectsItemsWithoutId(t *testing.T) {
+	preview, err := ParseMonobank(strings.NewReader(`[{"time": 1770113700, "amount": -100}]`), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkRows(t, preview, []wantRow{{Error: true}})
+}
+
+func TestParseMonobankWebhook(t *testing.T) {
+	preview := parseFixture(t, func(reader io.Reader, mapping *models.ImportMapping) (*models.ImportPreview, error) {
+		return ParseMonobankWebhook(reader)
+	}, "monobank_webhook.json", nil)
+	checkRows(t, preview, []wantRow{
+		{Amount: 950, SpentAt: "2026-02-03T10:15:00", Note: "WOG", Category: "Fuel", ExternalId: "monobank:ZuHWzqkKGVo="},
+	})
+}
+
+func TestParseMonobankWebhookRejectsOtherTypes(t *testing.T) {
+	for _, payload := range []string{`{"type": "AccountUpdate", "data": {}}`, `not json`} {
+		_, err := ParseMonobankWebhook(strings.NewReader(payload))
+		if err == nil {
+			t.Errorf("payload %s was accepted", payload)
+		}
+	}
+}
+
+func TestMccCategory(t *testing.T) {
+	defer ConfigureMcc(nil)
+	tests := []struct {
+		name  string
+		table map[string]string
+		mcc   int
+		want  string
+	}{
+		{"exact code", nil, 5411, "Groccesies"},
+		{"range", nil, 5813, "Cafes"},
+		{"exact code before range", nil, 4121, "Transport"},
+		{"narrowest range", nil, 5541, "Fuel"},
+		{"wider range", nil, 5511, "Car"},
+		{"unknown", nil, 1234, ""},
+		{"configured code", map[string]string{"5411": "7"}, 5411, "7"},
+		{"configured range", map[string]string{"5400-5499": "Food", "5410-5420": "Groceries"}, 5411, "Groceries"},
+		{"configured table replaces defaults", map[string]string{"5411": "Food"}, 5813, ""},
+		{"bad range ignored", map[string]string{"a-b": "Broken", "5800-5900": "Other"}, 5813, "Other"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ConfigureMcc(test.table)
+			if got := MccCategory(test.mcc); got != test.want {
+				t.Errorf("MccCategory(%d) = %q, want %q", test.mcc, got, test.want)
+			}
+		})
+	}
+}
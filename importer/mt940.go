@@ -0,0 +1,129 @@
+package importer
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"spendon/models"
+	"strings"
+	"time"
+)
+
+var (
+	mt940Tag          = regexp.MustCompile(`^:(\d{2}[A-Z]?):(.*)$`)
+	mt940Balance      = regexp.MustCompile(`^[CD](\d{6})([A-Z]{3})`)
+	mt940Line         = regexp.MustCompile(`^(\d{6})(\d{4})?(R?[CD])([A-Z])?([\d,]+)([NSF][A-Z0-9]{3})([^/]*)(?://(.*))?$`)
+	mt940InfoSubfield = regexp.MustCompile(`\?\d{2}`)
+)
+
+type mt940Statement struct {
+	account  string
+	currency string
+}
+
+type mt940Entry struct {
+	line  int
+	value string
+	info  string
+}
+
+// ParseMt940 streams SWIFT MT940 files line by line. A file may hold several statements, each
+// starting with :20:; the currency is taken from the opening balance. Debit lines are spending.
+func ParseMt940(reader io.Reader, mapping *models.ImportMapping) (*models.ImportPreview, error) {
+	preview := &models.ImportPreview{Rows: make([]models.ImportRow, 0)}
+	scanner := bufio.NewScanner(reader)
+	statement := mt940Statement{}
+	occurrences := make(map[string]int)
+	var entry *mt940Entry
+	tag := ""
+	flush := func() {
+		if entry != nil {
+			preview.Add(mt940Row(entry, &statement, occurrences))
+			entry = nil
+		}
+	}
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimRight(scanner.Text(), "\r ")
+		match := mt940Tag.FindStringSubmatch(text)
+		if match == nil {
+			if strings.HasPrefix(text, "-") || strings.HasPrefix(text, "{") {
+				continue
+			}
+			// Continuation of the previous field.
+			if entry != nil && tag == "86" {
+				entry.info += " " + text
+			} else if entry != nil && tag == "61" {
+				entry.info = strings.TrimSpace(entry.info + " " + text)
+			}
+			continue
+		}
+		tag = match[1]
+		value := match[2]
+		switch tag {
+		case "20":
+			flush()
+			statement = mt940Statement{}
+		case "25":
+			statement.account = strings.TrimSpace(value)
+		case "60F", "60M":
+			if balance := mt940Balance.FindStringSubmatch(value); balance != nil {
+				statement.currency = balance[2]
+			}
+		case "61":
+			flush()
+			entry = &mt940Entry{line: line, value: value}
+		case "86":
+			if entry != nil {
+				entry.info = strings.TrimSpace(value)
+			}
+		case "62F", "62M":
+			flush()
+		}
+	}
+	flush()
+	if err := scanner.Err(); err != nil {
+		return preview, err
+	}
+	return preview, nil
+}
+
+func mt940Row(entry *mt940Entry, statement *mt940Statement, occurrences map[string]int) models.ImportRow {
+	row := models.ImportRow{Line: entry.line, Currency: statement.currency}
+	match := mt940Line.FindStringSubmatch(entry.value)
+	if match == nil {
+		row.Error = fmt.Sprintf("statement line %q is not valid", entry.value)
+		return row
+	}
+	spentAt, err := time.Parse("060102", match[1])
+	if err != nil {
+		row.Error = err.Error()
+		return row
+	}
+	amount, err := ParseAmount(match[5], ",")
+	if err != nil {
+		row.Error = err.Error()
+		return row
+	}
+	// Spending is debit or a reversal of credit.
+	if match[3] == "C" || match[3] == "RD" {
+		amount = -amount
+	}
+	row.Transaction = models.Transaction{
+		Amount:  amount,
+		SpentAt: spentAt.Format(models.SpentAtLayout),
+		Note:    strings.Join(strings.Fields(mt940InfoSubfield.ReplaceAllString(entry.info, " ")), " "),
+	}
+	reference := strings.TrimSpace(match[8])
+	if reference == "" && strings.TrimSpace(match[7]) != "NONREF" {
+		reference = strings.TrimSpace(match[7])
+	}
+	if reference != "" {
+		row.Transaction.ExternalId = "mt940:" + statement.account + ":" + reference
+	} else {
+		key := strings.Join([]string{statement.account, entry.value, row.Transaction.Note}, "|")
+		occurrences[key]++
+		row.Transaction.ExternalId = StableId("mt940", key, fmt.Sprint(occurrences[key]))
+	}
+	return row
+}
@@ -0,0 +1,44 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseMt940(t *testing.T) {
+	preview := parseFixture(t, ParseMt940, "mt940_statement.txt", nil)
+	checkRows(t, preview, []wantRow{
+		{Amount: 42.5, SpentAt: "2024-03-04T00:00:00", Note: "Card payment Green Grocer Weekly groceries", ExternalId: "mt940:DE89370400440532013000:20240304-0001"},
+		{Amount: -2500, SpentAt: "2024-03-05T00:00:00", Note: "Salary March", ExternalId: "mt940:DE89370400440532013000:20240305-0002"},
+		// Reversing a credit takes the money back, so it is spending.
+		{Amount: 12, SpentAt: "2024-03-06T00:00:00", Note: "Refund reversal"},
+		{Amount: 19.99, SpentAt: "2024-03-07T00:00:00", Note: "Streaming subscription"},
+	})
+	for idx, line := range []int{6, 9, 11, 20} {
+		if row := preview.Rows[idx]; row.Line != line || row.Currency != "EUR" {
+			t.Errorf("row %d has line %d and currency %s, want %d and EUR", idx, row.Line, row.Currency, line)
+		}
+	}
+	for _, idx := range []int{2, 3} {
+		if id := preview.Rows[idx].Transaction.ExternalId; !strings.HasPrefix(id, "mt940:") {
+			t.Errorf("row %d without a reference got ExternalId %q", idx, id)
+		}
+	}
+	checkStableIds(t, ParseMt940, "mt940_statement.txt", preview)
+}
+
+func TestParseMt940BadLines(t *testing.T) {
+	statement := ":20:STMT\n:25:ACC\n:60F:C240101USD0,00\n" +
+		":61:240101D\n:86:no amount\n" +
+		":61:241301D5,00NTRFREF1\n" +
+		":61:240102RD7,25NTRFREF2\n:86:Reversed\nrefund\n:62F:C240102USD0,00\n"
+	preview, err := ParseMt940(strings.NewReader(statement), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkRows(t, preview, []wantRow{
+		{Error: true},
+		{Error: true},
+		{Amount: -7.25, SpentAt: "2024-01-02T00:00:00", Note: "Reversed refund", ExternalId: "mt940:ACC:REF2"},
+	})
+}
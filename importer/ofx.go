@@ -0,0 +1,120 @@
+package importer
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"spendon/models"
+	"strings"
+	"time"
+)
+
+// ParseOfx reads OFX/QFX statements, both SGML (1.x) and XML (2.x) flavours. Spending is negative
+// in OFX, so amounts are inverted. FITID, prefixed by the account id, becomes the ExternalId.
+func ParseOfx(reader io.Reader, mapping *models.ImportMapping) (*models.ImportPreview, error) {
+	preview := &models.ImportPreview{Rows: make([]models.ImportRow, 0)}
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	scanner.Split(scanOfxElements)
+	accountId := ""
+	var fields map[string]string
+	line := 0
+	for scanner.Scan() {
+		tag, value := splitOfxElement(scanner.Text())
+		switch tag {
+		case "ACCTID":
+			accountId = value
+		case "STMTTRN":
+			fields = make(map[string]string)
+		case "/STMTTRN":
+			if fields == nil {
+				continue
+			}
+			line++
+			preview.Add(ofxRow(line, accountId, fields))
+			fields = nil
+		default:
+			if fields != nil && value != "" && !strings.HasPrefix(tag, "/") {
+				fields[tag] = value
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return preview, err
+	}
+	return preview, nil
+}
+
+func ofxRow(line int, accountId string, fields map[string]string) models.ImportRow {
+	row := models.ImportRow{Line: line}
+	spentAt, err := parseOfxDate(fields["DTPOSTED"])
+	if err != nil {
+		row.Error = err.Error()
+		return row
+	}
+	amount, err := ParseAmount(fields["TRNAMT"], ".")
+	if err != nil {
+		row.Error = err.Error()
+		return row
+	}
+	row.Transaction = models.Transaction{
+		Amount:  -amount,
+		SpentAt: spentAt.Format(models.SpentAtLayout),
+		Note:    joinNote(fields["NAME"], fields["MEMO"]),
+	}
+	if fitId := fields["FITID"]; fitId != "" {
+		row.Transaction.ExternalId = "ofx:" + accountId + ":" + fitId
+	} else {
+		row.Transaction.ExternalId = StableId("ofx", accountId, fields["DTPOSTED"], fields["TRNAMT"], fields["NAME"], fields["MEMO"])
+	}
+	return row
+}
+
+// parseOfxDate reads YYYYMMDD[HHMMSS[.XXX]][[gmt offset:tz name]].
+func parseOfxDate(value string) (time.Time, error) {
+	if idx := strings.IndexAny(value, ".["); idx >= 0 {
+		value = value[:idx]
+	}
+	layouts := []string{"20060102150405", "200601021504", "20060102"}
+	for _, layout := range layouts {
+		if len(value) == len(layout) {
+			if date, err := time.Parse(layout, value); err == nil {
+				return date, nil
+			}
+		}
+	}
+	return time.Time{}, fmt.Errorf("date %q is not an OFX date", value)
+}
+
+// scanOfxElements splits the input into "<TAG>value" tokens, so SGML elements without
+// closing tags and XML elements on one line are handled the same way.
+func scanOfxElements(data []byte, atEOF bool) (int, []byte, error) {
+	start := strings.IndexByte(string(data), '<')
+	if start < 0 {
+		if atEOF {
+			return len(data), nil, nil
+		}
+		return 0, nil, nil
+	}
+	next := strings.IndexByte(string(data[start+1:]), '<')
+	if next < 0 {
+		if atEOF {
+			return len(data), data[start:], nil
+		}
+		return start, nil, nil
+	}
+	end := start + 1 + next
+	return end, data[start:end], nil
+}
+
+func splitOfxElement(element string) (string, string) {
+	closing := strings.IndexByte(element, '>')
+	if closing < 0 {
+		return "", ""
+	}
+	return strings.ToUpper(strings.TrimSpace(element[1:closing])), strings.TrimSpace(unescapeOfx(element[closing+1:]))
+}
+
+func unescapeOfx(value string) string {
+	return strings.NewReplacer("&amp;", "&", "&lt;", "<", "&gt;", ">", "&quot;", "\"", "&apos;", "'").Replace(value)
+}
@@ -0,0 +1,38 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseOfxSgml(t *testing.T) {
+	preview := parseFixture(t, ParseOfx, "statement.ofx", nil)
+	checkRows(t, preview, []wantRow{
+		{Amount: 1234.5, SpentAt: "2026-02-03T10:15:00", Note: "SILPO - Groceries", ExternalId: "ofx:26201234567890:2026020300001"},
+		{Amount: -25000, SpentAt: "2026-02-15T00:00:00", Note: "SALARY", ExternalId: "ofx:26201234567890:2026021500002"},
+		{Amount: 89.99, SpentAt: "2026-02-20T00:00:00", Note: "NETFLIX & CO - Subscription"},
+	})
+	if id := preview.Rows[2].Transaction.ExternalId; !strings.HasPrefix(id, "ofx:") {
+		t.Errorf("a transaction without FITID got ExternalId %q", id)
+	}
+	checkStableIds(t, ParseOfx, "statement.ofx", preview)
+}
+
+func TestParseQfxXml(t *testing.T) {
+	preview := parseFixture(t, ParseOfx, "statement.qfx", nil)
+	checkRows(t, preview, []wantRow{
+		{Amount: 560, SpentAt: "2026-03-05T08:30:00", Note: "WOG - Fuel", ExternalId: "ofx:26209876543210:A-1001"},
+		{Amount: 120, SpentAt: "2026-03-12T00:00:00", Note: "Pharmacy", ExternalId: "ofx:26209876543210:A-1002"},
+	})
+	checkStableIds(t, ParseOfx, "statement.qfx", preview)
+}
+
+func TestParseOfxBadRows(t *testing.T) {
+	statement := "<OFX><STMTTRN><DTPOSTED>yesterday<TRNAMT>-1.00</STMTTRN>" +
+		"<STMTTRN><DTPOSTED>20260101<TRNAMT>lots</STMTTRN></OFX>"
+	preview, err := ParseOfx(strings.NewReader(statement), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkRows(t, preview, []wantRow{{Error: true}, {Error: true}})
+}
@@ -0,0 +1,77 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"spendon/models"
+	"time"
+)
+
+type privatbankTransaction struct {
+	Id       string `json:"ID"`
+	Date     string `json:"DAT_OD"`
+	Time     string `json:"TIM_P"`
+	Sum      string `json:"SUM"`
+	TranType string `json:"TRANTYPE"`
+	Purpose  string `json:"OSND"`
+	Currency string `json:"CCY"`
+}
+
+type privatbankStatement struct {
+	Transactions []privatbankTransaction `json:"transactions"`
+}
+
+// ParsePrivatbank reads the PrivatBank statement export, either the full response with
+// a "transactions" list or the list alone. Debit (D) rows are spending.
+func ParsePrivatbank(reader io.Reader, mapping *models.ImportMapping) (*models.ImportPreview, error) {
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	statement := privatbankStatement{}
+	if trimmed := bytes.TrimSpace(content); len(trimmed) > 0 && trimmed[0] == '[' {
+		err = json.Unmarshal(trimmed, &statement.Transactions)
+	} else {
+		err = json.Unmarshal(content, &statement)
+	}
+	if err != nil {
+		return nil, err
+	}
+	preview := &models.ImportPreview{Rows: make([]models.ImportRow, 0, len(statement.Transactions))}
+	for idx, transaction := range statement.Transactions {
+		preview.Add(privatbankRow(idx+1, &transaction))
+	}
+	return preview, nil
+}
+
+func privatbankRow(line int, transaction *privatbankTransaction) models.ImportRow {
+	row := models.ImportRow{Line: line}
+	spentAt, err := time.Parse("02.01.2006 15:04", transaction.Date+" "+transaction.Time)
+	if err != nil {
+		spentAt, err = time.Parse("02.01.2006", transaction.Date)
+	}
+	if err != nil {
+		row.Error = "date " + transaction.Date + " is not a PrivatBank date"
+		return row
+	}
+	amount, err := ParseAmount(transaction.Sum, ".")
+	if err != nil {
+		row.Error = err.Error()
+		return row
+	}
+	if transaction.TranType == "C" {
+		amount = -amount
+	}
+	row.Transaction = models.Transaction{
+		Amount:  amount,
+		SpentAt: spentAt.Format(models.SpentAtLayout),
+		Note:    transaction.Purpose,
+	}
+	if transaction.Id != "" {
+		row.Transaction.ExternalId = "privatbank:" + transaction.Id
+	} else {
+		row.Transaction.ExternalId = StableId("privatbank", transaction.Date, transaction.Time, transaction.Sum, transaction.TranType, transaction.Purpose)
+	}
+	return row
+}
@@ -0,0 +1,35 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParsePrivatbank(t *testing.T) {
+	preview := parseFixture(t, ParsePrivatbank, "privatbank_statement.json", nil)
+	checkRows(t, preview, []wantRow{
+		{Amount: 450, SpentAt: "2026-02-03T10:15:00", Note: "Оплата за послуги зв'язку", ExternalId: "privatbank:2035671235"},
+		{Amount: -120.5, SpentAt: "2026-02-05T16:40:00", Note: "Повернення коштів", ExternalId: "privatbank:2035671290"},
+	})
+	checkStableIds(t, ParsePrivatbank, "privatbank_statement.json", preview)
+}
+
+func TestParsePrivatbankList(t *testing.T) {
+	statement := `[
+		{"DAT_OD": "07.02.2026", "SUM": "99.90", "TRANTYPE": "D", "OSND": "Кава"},
+		{"DAT_OD": "31.02.2026", "SUM": "1.00", "TRANTYPE": "D"},
+		{"DAT_OD": "08.02.2026", "SUM": "", "TRANTYPE": "D"}
+	]`
+	preview, err := ParsePrivatbank(strings.NewReader(statement), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkRows(t, preview, []wantRow{
+		{Amount: 99.9, SpentAt: "2026-02-07T00:00:00", Note: "Кава"},
+		{Error: true},
+		{Error: true},
+	})
+	if id := preview.Rows[0].Transaction.ExternalId; !strings.HasPrefix(id, "privatbank:") || len(id) <= len("privatbank:") {
+		t.Errorf("a row without ID got ExternalId %q", id)
+	}
+}
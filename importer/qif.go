@@ -0,0 +1,89 @@
+package importer
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"spendon/models"
+	"strings"
+	"time"
+)
+
+var qifDateLayouts = []string{"01/02/2006", "1/2/2006", "01/02/06", "1/2/06", "01/02'06", "1/2'06", "02.01.2006", "2006-01-02"}
+
+// ParseQif reads QIF bank and cash accounts. Spending is negative in QIF, so amounts are inverted.
+// QIF has no transaction ids, so ExternalId is a hash of the record and its position among equal records.
+func ParseQif(reader io.Reader, mapping *models.ImportMapping) (*models.ImportPreview, error) {
+	preview := &models.ImportPreview{Rows: make([]models.ImportRow, 0)}
+	scanner := bufio.NewScanner(reader)
+	fields := make(map[byte]string)
+	occurrences := make(map[string]int)
+	line, recordLine := 0, 1
+	for scanner.Scan() {
+		line++
+		text := strings.TrimRight(scanner.Text(), "\r")
+		if text == "" || strings.HasPrefix(text, "!") {
+			recordLine = line + 1
+			continue
+		}
+		if text[0] == '^' {
+			if len(fields) > 0 {
+				preview.Add(qifRow(recordLine, fields, mapping, occurrences))
+			}
+			fields = make(map[byte]string)
+			recordLine = line + 1
+			continue
+		}
+		if _, ok := fields[text[0]]; !ok {
+			fields[text[0]] = strings.TrimSpace(text[1:])
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return preview, err
+	}
+	if len(fields) > 0 {
+		preview.Add(qifRow(recordLine, fields, mapping, occurrences))
+	}
+	return preview, nil
+}
+
+func qifRow(line int, fields map[byte]string, mapping *models.ImportMapping, occurrences map[string]int) models.ImportRow {
+	row := models.ImportRow{Line: line, Category: fields['L']}
+	spentAt, err := parseQifDate(fields['D'], mapping.DateFormat)
+	if err != nil {
+		row.Error = err.Error()
+		return row
+	}
+	amountText := fields['T']
+	if amountText == "" {
+		amountText = fields['U']
+	}
+	amount, err := ParseAmount(amountText, mapping.DecimalSeparator)
+	if err != nil {
+		row.Error = err.Error()
+		return row
+	}
+	key := strings.Join([]string{fields['D'], amountText, fields['P'], fields['M']}, "|")
+	occurrences[key]++
+	row.Transaction = models.Transaction{
+		Amount:     -amount,
+		SpentAt:    spentAt.Format(models.SpentAtLayout),
+		Note:       joinNote(fields['P'], fields['M']),
+		ExternalId: StableId("qif", key, fmt.Sprint(occurrences[key])),
+	}
+	return row
+}
+
+func parseQifDate(value, dateFormat string) (time.Time, error) {
+	value = strings.ReplaceAll(strings.TrimSpace(value), " ", "")
+	layouts := qifDateLayouts
+	if dateFormat != "" {
+		layouts = []string{dateFormat}
+	}
+	for _, layout := range layouts {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("date %q is not a QIF date", value)
+}
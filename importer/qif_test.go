@@ -0,0 +1,34 @@
+package importer
+
+import (
+	"spendon/models"
+	"strings"
+	"testing"
+)
+
+func TestParseQif(t *testing.T) {
+	preview := parseFixture(t, ParseQif, "statement.qif", nil)
+	checkRows(t, preview, []wantRow{
+		{Amount: 1234.5, SpentAt: "2026-02-03T00:00:00", Note: "Silpo - Groceries", Category: "Groccesies"},
+		{Amount: -25000, SpentAt: "2026-02-15T00:00:00", Note: "Salary"},
+		{Amount: 89.99, SpentAt: "2026-02-20T00:00:00", Note: "Netflix - Subscription", Category: "Services"},
+		{Amount: 89.99, SpentAt: "2026-02-20T00:00:00", Note: "Netflix - Subscription", Category: "Services"},
+		{Error: true},
+	})
+	for idx, line := range []int{2, 8, 12, 18, 24} {
+		if preview.Rows[idx].Line != line {
+			t.Errorf("row %d starts at line %d, want %d", idx, preview.Rows[idx].Line, line)
+		}
+	}
+	// The two equal Netflix records are separate transactions, told apart by their position.
+	checkStableIds(t, ParseQif, "statement.qif", preview)
+}
+
+func TestParseQifDateFormat(t *testing.T) {
+	statement := "!Type:Cash\nD03/02/2026\nT-10,5\nPMarket\n^\n"
+	preview, err := ParseQif(strings.NewReader(statement), &models.ImportMapping{DateFormat: "02/01/2006", DecimalSeparator: ","})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkRows(t, preview, []wantRow{{Amount: 10.5, SpentAt: "2026-02-03T00:00:00", Note: "Market"}})
+}
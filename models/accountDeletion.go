@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+type AccountDeletions []AccountDeletion
+
+// AccountDeletionRequest repeats the password, so a stolen token alone cannot delete an account.
+type AccountDeletionRequest struct {
+	Password string
+}
+
+// AccountDeletion is kept after the account is erased as the audit record of the deletion.
+// It holds no personal data besides the id the user had.
+type AccountDeletion struct {
+	Id           int64
+	UserId       int64
+	RequestedAt  time.Time
+	ScheduledFor time.Time
+	CancelledAt  *time.Time
+	ErasedAt     *time.Time
+	ErasedRows   *int64
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"net/mail"
+	"net/url"
+)
+
+// AlertDestination is where the budget alerts of a user go. Alerts are not sent when both are empty.
+type AlertDestination struct {
+	WebhookUrl string `validate:"max=2000"`
+	Email      string `validate:"max=320"`
+}
+
+func (destination *AlertDestination) Check() []FieldError {
+	var fieldErrors []FieldError
+	if destination.WebhookUrl != "" {
+		parsed, err := url.Parse(destination.WebhookUrl)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			fieldErrors = append(fieldErrors, FieldError{Field: "WebhookUrl", Message: "must be an http or https URL"})
+		}
+	}
+	if destination.Email != "" {
+		address, err := mail.ParseAddress(destination.Email)
+		if err != nil || address.Address != destination.Email {
+			fieldErrors = append(fieldErrors, FieldError{Field: "Email", Message: "must be a bare email address"})
+		}
+	}
+	return fieldErrors
+}
+
+func (destination *AlertDestination) IsEmpty() bool {
+	return destination.WebhookUrl == "" && destination.Email == ""
+}
@@ -0,0 +1,42 @@
+package models
+
+// Error codes are part of the API and must not change once released; clients switch on them.
+const (
+	ValidationFailedCode     = "validation_failed"
+	InvalidBodyCode          = "invalid_body"
+	BodyTooLargeCode         = "body_too_large"
+	UnauthorizedCode         = "unauthorized"
+	ForbiddenCode            = "forbidden"
+	NotFoundCode             = "not_found"
+	MethodNotAllowedCode     = "method_not_allowed"
+	ConflictCode             = "conflict"
+	VersionConflictCode      = "version_conflict"
+	IdempotencyKeyReusedCode = "idempotency_key_reused"
+	PreconditionRequiredCode = "precondition_required"
+	InternalErrorCode        = "internal_error"
+)
+
+// ErrorResponse is the body of every failed request.
+type ErrorResponse struct {
+	Error ApiError
+}
+
+type ApiError struct {
+	Code    string
+	Message string
+	Details []FieldError `json:",omitempty"`
+	// Current is the stored state of the resource when the request failed with a version conflict.
+	Current   interface{} `json:",omitempty"`
+	RequestId string      `json:",omitempty"`
+}
+
+// FieldError points at the request field that failed validation. It is also returned as an error
+// by request models, so handlers can put it into the response details.
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+func (fieldError *FieldError) Error() string {
+	return fieldError.Field + ": " + fieldError.Message
+}
@@ -0,0 +1,69 @@
+package models
+
+import "time"
+
+// BackupVersion is written to the manifest of every backup. Restoring an archive with a newer
+// version is refused, older versions have to stay readable.
+const BackupVersion = 1
+
+type BackupManifest struct {
+	Version   int
+	CreatedAt time.Time
+	Login     string
+}
+
+type BackupProfile struct {
+	Login    string
+	Currency string
+}
+
+type RecurringRuns []RecurringRun
+
+// RecurringRun is an occurrence the scheduler already generated. TransactionId is empty for
+// skipped occurrences.
+type RecurringRun struct {
+	OccurrenceDate time.Time
+	TransactionId  *int64
+}
+
+type BackupRecurringRule struct {
+	RecurringRule
+	Exceptions RecurringExceptions
+	Runs       RecurringRuns
+}
+
+// Backup holds everything a user owns. Ids are the ones of the source instance and are
+// remapped on restore.
+type Backup struct {
+	Manifest       BackupManifest
+	Profile        BackupProfile
+	Categories     Categories
+	Transactions   []Transaction
+	RecurringRules []BackupRecurringRule
+	Budgets        Budgets
+	SavingsGoals   SavingsGoals
+	ImportMappings SavedImportMappings
+}
+
+type RestoreCount struct {
+	Inserted int
+	Skipped  int
+}
+
+// RestoreCategoryCount counts how archived categories were matched. Categories are shared by every
+// user, so a restore never creates them: Defaulted ones had no category of the same name and
+// their items went to the default category.
+type RestoreCategoryCount struct {
+	Matched   int
+	Defaulted int
+}
+
+// RestoreResult counts the restored items. Skipped items already existed in the account.
+type RestoreResult struct {
+	Categories     RestoreCategoryCount
+	Transactions   RestoreCount
+	RecurringRules RestoreCount
+	Budgets        RestoreCount
+	SavingsGoals   RestoreCount
+	ImportMappings RestoreCount
+}
@@ -0,0 +1,97 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	MonthlyPeriod = iota
+	WeeklyPeriod
+	YearlyPeriod
+)
+
+type Budgets []Budget
+
+type BudgetStatuses []BudgetStatus
+
+type Budget struct {
+	Id         int64
+	CategoryId int32   `validate:"required,category"`
+	Period     int     `validate:"oneof=0 1 2"`
+	Amount     float32 `validate:"required,finite,min=0,max=1000000000"`
+	Rollover   bool
+}
+
+type BudgetRemove struct {
+	BudgetId int64
+}
+
+type BudgetStatus struct {
+	BudgetId    int64
+	CategoryId  int32
+	Period      int
+	PeriodStart time.Time
+	PeriodEnd   time.Time
+	Limit       float32
+	RolledOver  float32
+	Spent       float32
+	Remaining   float32
+	Percentage  float32
+	Projected   float32
+}
+
+// PeriodBounds returns the start (inclusive) and the end (exclusive) of the budget period containing at.
+// Weekly periods start on Monday.
+func (budget *Budget) PeriodBounds(at time.Time) (time.Time, time.Time) {
+	switch budget.Period {
+	case WeeklyPeriod:
+		daysFromMonday := (int(at.Weekday()) + 6) % 7
+		start := time.Date(at.Year(), at.Month(), at.Day()-daysFromMonday, 0, 0, 0, 0, at.Location())
+		return start, start.AddDate(0, 0, 7)
+	case YearlyPeriod:
+		start := time.Date(at.Year(), time.January, 1, 0, 0, 0, 0, at.Location())
+		return start, start.AddDate(1, 0, 0)
+	default:
+		start := time.Date(at.Year(), at.Month(), 1, 0, 0, 0, 0, at.Location())
+		return start, start.AddDate(0, 1, 0)
+	}
+}
+
+// Filters selects the transactions of the budget category spent within [start, end).
+func (budget *Budget) Filters(start, end time.Time) FilterBatch {
+	return FilterBatch{
+		{Property: SpentAt, Operator: GreaterOrEqual, Value: start.Format(SpentAtLayout)},
+		{Property: SpentAt, Operator: Less, Value: end.Format(SpentAtLayout)},
+		{Property: CategoryId, Operator: Equal, Value: fmt.Sprintf("%d", budget.CategoryId)},
+	}
+}
+
+// Status builds the budget state at the given moment. previousSpent is only used when
+// the budget rolls unused amounts over from the previous period.
+func (budget *Budget) Status(at time.Time, spent, previousSpent float32) BudgetStatus {
+	start, end := budget.PeriodBounds(at)
+	status := BudgetStatus{
+		BudgetId:    budget.Id,
+		CategoryId:  budget.CategoryId,
+		Period:      budget.Period,
+		PeriodStart: start,
+		PeriodEnd:   end,
+		Limit:       budget.Amount,
+		Spent:       spent,
+		Projected:   spent,
+	}
+	if budget.Rollover && previousSpent < budget.Amount {
+		status.RolledOver = budget.Amount - previousSpent
+		status.Limit += status.RolledOver
+	}
+	status.Remaining = status.Limit - spent
+	if status.Limit > 0 {
+		status.Percentage = spent / status.Limit * 100
+	}
+	elapsed := at.Sub(start)
+	if elapsed > 0 && at.Before(end) {
+		status.Projected = float32(float64(spent) * float64(end.Sub(start)) / float64(elapsed))
+	}
+	return status
+}
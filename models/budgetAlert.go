@@ -0,0 +1,7 @@
+package models
+
+type BudgetAlert struct {
+	UserLogin string
+	Threshold int
+	Status    BudgetStatus
+}
@@ -0,0 +1,80 @@
+package models
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestPeriodBounds(t *testing.T) {
+	at := time.Date(2024, time.March, 17, 15, 30, 0, 0, time.UTC)
+	tests := []struct {
+		period     int
+		at         time.Time
+		start, end time.Time
+	}{
+		{MonthlyPeriod, at, time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, time.April, 1, 0, 0, 0, 0, time.UTC)},
+		{MonthlyPeriod, time.Date(2024, time.December, 31, 23, 0, 0, 0, time.UTC), time.Date(2024, time.December, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		// 17 March 2024 is a Sunday, the last day of the week that started on Monday the 11th.
+		{WeeklyPeriod, at, time.Date(2024, time.March, 11, 0, 0, 0, 0, time.UTC), time.Date(2024, time.March, 18, 0, 0, 0, 0, time.UTC)},
+		{WeeklyPeriod, time.Date(2024, time.March, 18, 0, 0, 0, 0, time.UTC), time.Date(2024, time.March, 18, 0, 0, 0, 0, time.UTC), time.Date(2024, time.March, 25, 0, 0, 0, 0, time.UTC)},
+		{WeeklyPeriod, time.Date(2024, time.January, 2, 8, 0, 0, 0, time.UTC), time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, time.January, 8, 0, 0, 0, 0, time.UTC)},
+		{YearlyPeriod, at, time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, test := range tests {
+		budget := Budget{Period: test.period}
+		start, end := budget.PeriodBounds(test.at)
+		if !start.Equal(test.start) || !end.Equal(test.end) {
+			t.Errorf("period %d at %s: got [%s, %s), want [%s, %s)", test.period, test.at, start, end, test.start, test.end)
+		}
+	}
+}
+
+func closeTo(got, want float32) bool {
+	return math.Abs(float64(got-want)) < 0.01
+}
+
+func TestStatus(t *testing.T) {
+	budget := Budget{Id: 4, CategoryId: 2, Period: MonthlyPeriod, Amount: 300}
+	// Halfway through April: 15 of 30 days have passed.
+	at := time.Date(2024, time.April, 16, 0, 0, 0, 0, time.UTC)
+	status := budget.Status(at, 120, 500)
+
+	if status.BudgetId != 4 || status.CategoryId != 2 || status.Limit != 300 || status.RolledOver != 0 {
+		t.Errorf("got %+v", status)
+	}
+	if !closeTo(status.Remaining, 180) || !closeTo(status.Percentage, 40) || !closeTo(status.Projected, 240) {
+		t.Errorf("got remaining %v, percentage %v, projected %v", status.Remaining, status.Percentage, status.Projected)
+	}
+
+	overspent := budget.Status(at, 450, 0)
+	if !closeTo(overspent.Remaining, -150) || !closeTo(overspent.Percentage, 150) {
+		t.Errorf("got remaining %v, percentage %v", overspent.Remaining, overspent.Percentage)
+	}
+
+	// At the very start of a period nothing can be projected yet.
+	start := budget.Status(time.Date(2024, time.April, 1, 0, 0, 0, 0, time.UTC), 0, 0)
+	if start.Projected != 0 || start.Percentage != 0 {
+		t.Errorf("got projected %v, percentage %v", start.Projected, start.Percentage)
+	}
+}
+
+func TestStatusRollsOverUnusedAmount(t *testing.T) {
+	at := time.Date(2024, time.April, 16, 0, 0, 0, 0, time.UTC)
+	budget := Budget{Period: MonthlyPeriod, Amount: 300, Rollover: true}
+
+	status := budget.Status(at, 150, 200)
+	if status.RolledOver != 100 || status.Limit != 400 || !closeTo(status.Remaining, 250) || !closeTo(status.Percentage, 37.5) {
+		t.Errorf("got %+v", status)
+	}
+	// An overspent previous period does not lower the limit.
+	status = budget.Status(at, 150, 350)
+	if status.RolledOver != 0 || status.Limit != 300 {
+		t.Errorf("got %+v", status)
+	}
+	budget.Rollover = false
+	status = budget.Status(at, 150, 0)
+	if status.RolledOver != 0 || status.Limit != 300 {
+		t.Errorf("got %+v", status)
+	}
+}
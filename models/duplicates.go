@@ -0,0 +1,101 @@
+package models
+
+import (
+	"math"
+	"strings"
+	"unicode/utf8"
+)
+
+type DuplicateCandidates []DuplicateCandidate
+
+type DuplicateCandidate struct {
+	First      Transaction
+	Second     Transaction
+	Confidence float32
+}
+
+type DuplicatesRequest struct {
+	DaysWindow    int
+	MinConfidence float32
+}
+
+type TransactionMerge struct {
+	KeepId   int64
+	RemoveId int64
+}
+
+func (merge *TransactionMerge) Check() []FieldError {
+	if merge.KeepId == merge.RemoveId {
+		return []FieldError{{Field: "RemoveId", Message: "must differ from KeepId"}}
+	}
+	return nil
+}
+
+const (
+	dateWeight     = 0.4
+	categoryWeight = 0.3
+	noteWeight     = 0.3
+)
+
+// DuplicateConfidence scores two transactions of the same amount from 0 to 1 by date proximity
+// within daysWindow, equal category and similarity of their notes.
+func DuplicateConfidence(first, second *Transaction, daysWindow int) float32 {
+	if first.Amount != second.Amount {
+		return 0
+	}
+	confidence := 0.0
+	firstDate, firstErr := ParseSpentAt(first.SpentAt)
+	secondDate, secondErr := ParseSpentAt(second.SpentAt)
+	if firstErr == nil && secondErr == nil {
+		days := math.Abs(firstDate.Sub(secondDate).Hours()) / 24
+		if days <= float64(daysWindow) {
+			confidence += dateWeight * (1 - days/float64(daysWindow+1))
+		}
+	}
+	if first.CategoryId == second.CategoryId {
+		confidence += categoryWeight
+	}
+	confidence += noteWeight * NoteSimilarity(first.Note, second.Note)
+	return float32(confidence)
+}
+
+// NoteSimilarity is 1 minus the Levenshtein distance of the normalized notes divided by the longer length.
+func NoteSimilarity(first, second string) float64 {
+	first = strings.ToLower(strings.Join(strings.Fields(first), " "))
+	second = strings.ToLower(strings.Join(strings.Fields(second), " "))
+	longest := utf8.RuneCountInString(first)
+	if secondLength := utf8.RuneCountInString(second); secondLength > longest {
+		longest = secondLength
+	}
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(levenshtein([]rune(first), []rune(second)))/float64(longest)
+}
+
+func levenshtein(first, second []rune) int {
+	previous := make([]int, len(second)+1)
+	current := make([]int, len(second)+1)
+	for idx := range previous {
+		previous[idx] = idx
+	}
+	for i := 1; i <= len(first); i++ {
+		current[0] = i
+		for j := 1; j <= len(second); j++ {
+			cost := 1
+			if first[i-1] == second[j-1] {
+				cost = 0
+			}
+			current[j] = minInt(minInt(previous[j]+1, current[j-1]+1), previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(second)]
+}
+
+func minInt(first, second int) int {
+	if first < second {
+		return first
+	}
+	return second
+}
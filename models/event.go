@@ -0,0 +1,23 @@
+package models
+
+const (
+	TransactionCreatedEvent = "transaction.created"
+	TransactionUpdatedEvent = "transaction.updated"
+	TransactionDeletedEvent = "transaction.deleted"
+	BudgetChangedEvent      = "budget.changed"
+	BudgetRemovedEvent      = "budget.removed"
+	// ResetEvent tells a client that events were missed and its data has to be fetched again.
+	ResetEvent = "reset"
+)
+
+// Event is a change pushed to the clients of the user. Only the field matching Type is set.
+type Event struct {
+	Id   string `json:",omitempty"`
+	Type string
+	// UserId is empty for changes every user sees.
+	UserId        int64        `json:"-"`
+	Transaction   *Transaction `json:",omitempty"`
+	TransactionId int64        `json:",omitempty"`
+	Budget        *Budget      `json:",omitempty"`
+	BudgetId      int64        `json:",omitempty"`
+}
@@ -0,0 +1,18 @@
+package models
+
+type ExportRequest struct {
+	Format  string
+	Filters FilterBatch
+}
+
+// ExportRow is a transaction as written to an export file, with its category name resolved
+// and the amount formatted in the user's currency.
+type ExportRow struct {
+	Id              int64
+	SpentAt         string
+	Category        string
+	Amount          float32
+	Currency        string `json:",omitempty"`
+	FormattedAmount string
+	Note            string
+}
@@ -2,6 +2,7 @@ package models
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 )
 
@@ -13,15 +14,32 @@ type FilterSettings struct {
 }
 
 type FilteredRequest struct {
-	PageNumber int64
-	Pagination int64
+	PageNumber int64 `validate:"min=0"`
+	Pagination int64 `validate:"required,min=1,max=1000"`
 	Filters    FilterBatch
 }
 
 type FilterModel struct {
-	Property int
-	Value    string
-	Operator int
+	Property int    `validate:"oneof=0 1 2 3"`
+	Value    string `validate:"max=500"`
+	Operator int    `validate:"oneof=0 1 2 3 4 5"`
+}
+
+// Check makes sure Value can be compared with the filtered property.
+func (filterModel *FilterModel) Check() []FieldError {
+	var err error
+	switch filterModel.Property {
+	case Amount:
+		_, err = strconv.ParseFloat(filterModel.Value, 32)
+	case SpentAt:
+		_, err = ParseSpentAt(filterModel.Value)
+	case CategoryId:
+		_, err = strconv.ParseInt(filterModel.Value, 10, 32)
+	}
+	if err != nil {
+		return []FieldError{{Field: "Value", Message: fmt.Sprintf("%q does not fit %s", filterModel.Value, fieldsMap[filterModel.Property])}}
+	}
+	return nil
 }
 
 func (filterModel *FilterModel) Build(nameForParameter string) (string, interface{}, error) {
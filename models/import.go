@@ -0,0 +1,75 @@
+package models
+
+const (
+	// KeepSign takes amounts as they are in the statement.
+	KeepSign = iota
+	// InvertSign is for statements where spending is negative.
+	InvertSign
+	// DebitCredit takes spending from the Debit column and income from the Credit column.
+	DebitCredit
+)
+
+const (
+	AmountColumn   = "Amount"
+	DebitColumn    = "Debit"
+	CreditColumn   = "Credit"
+	SpentAtColumn  = "SpentAt"
+	NoteColumn     = "Note"
+	CategoryColumn = "Category"
+)
+
+// ImportMapping describes how a statement file is turned into transactions.
+// Columns maps a column name from above to its zero-based index in the file.
+type ImportMapping struct {
+	Delimiter         string
+	Encoding          string
+	DateFormat        string
+	DecimalSeparator  string
+	SignConvention    int
+	SkipRows          int
+	Columns           map[string]int
+	DefaultCategoryId int32
+}
+
+type SavedImportMappings []SavedImportMapping
+
+type SavedImportMapping struct {
+	Id      int64
+	Name    string
+	Mapping ImportMapping
+}
+
+type SavedImportMappingRemove struct {
+	MappingId int64
+}
+
+type ImportRow struct {
+	Line        int
+	Category    string `json:",omitempty"`
+	Currency    string `json:",omitempty"`
+	Transaction Transaction
+	Error       string `json:",omitempty"`
+}
+
+type ImportPreview struct {
+	Rows   []ImportRow
+	Valid  int
+	Errors int
+}
+
+type ImportResult struct {
+	Inserted   int
+	Duplicates int
+	Failed     int
+	// Rejected are the rows that were not inserted because they could not be read or are not valid.
+	Rejected []ImportRow
+}
+
+func (preview *ImportPreview) Add(row ImportRow) {
+	preview.Rows = append(preview.Rows, row)
+	if row.Error == "" {
+		preview.Valid++
+	} else {
+		preview.Errors++
+	}
+}
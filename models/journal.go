@@ -0,0 +1,48 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	JournalBeancount = "beancount"
+	JournalLedger    = "ledger"
+)
+
+// JournalRequest asks for a plain-text accounting journal of the transactions spent between
+// From and To, both inclusive dates in 2006-01-02 form and both optional.
+type JournalRequest struct {
+	Dialect string
+	From    string
+	To      string
+	Naming  JournalNaming
+}
+
+// JournalNaming is the account naming scheme of a journal. ExpenseAccount may contain
+// {category}, which is replaced with the category name.
+type JournalNaming struct {
+	AssetAccount   string
+	ExpenseAccount string
+	Currency       string
+}
+
+// Filters returns the date range of the request as a filter batch.
+func (request *JournalRequest) Filters() (FilterBatch, error) {
+	filters := make(FilterBatch, 0, 2)
+	if request.From != "" {
+		from, err := time.Parse("2006-01-02", request.From)
+		if err != nil {
+			return nil, &FieldError{Field: "From", Message: fmt.Sprintf("date %q is not valid, use YYYY-MM-DD", request.From)}
+		}
+		filters = append(filters, FilterModel{Property: SpentAt, Operator: GreaterOrEqual, Value: from.Format(SpentAtLayout)})
+	}
+	if request.To != "" {
+		to, err := time.Parse("2006-01-02", request.To)
+		if err != nil {
+			return nil, &FieldError{Field: "To", Message: fmt.Sprintf("date %q is not valid, use YYYY-MM-DD", request.To)}
+		}
+		filters = append(filters, FilterModel{Property: SpentAt, Operator: Less, Value: to.AddDate(0, 0, 1).Format(SpentAtLayout)})
+	}
+	return filters, nil
+}
@@ -5,8 +5,8 @@ import (
 )
 
 type Login struct {
-	UserName string
-	Password string
+	UserName string `validate:"required"`
+	Password string `validate:"required"`
 }
 
 type LoginResult struct {
@@ -20,6 +20,16 @@ type DbLogin struct {
 }
 
 type RegisterModel struct {
+	Login    string `validate:"required,min=3,max=64"`
+	Password string `validate:"required,password,max=128"`
+}
+
+// Profile describes the signed in user.
+type Profile struct {
+	Id       int64
 	Login    string
-	Password string
+	Currency string
+	IsAdmin  bool
+	// PendingDeletion is set while the account is scheduled for deletion.
+	PendingDeletion *AccountDeletion `json:",omitempty"`
 }
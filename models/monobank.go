@@ -0,0 +1,5 @@
+package models
+
+type MonobankWebhookRegistration struct {
+	WebhookUrl string
+}
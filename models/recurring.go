@@ -0,0 +1,146 @@
+package models
+
+import (
+	"time"
+)
+
+const SpentAtLayout = "2006-01-02T15:04:05"
+
+const (
+	Daily = iota
+	Weekly
+	Monthly
+)
+
+type RecurringRules []RecurringRule
+
+type RecurringExceptions []RecurringException
+
+type RecurringRule struct {
+	Id         int64
+	UserId     int64   `json:"-"`
+	Amount     float32 `validate:"required,finite,min=-1000000000,max=1000000000"`
+	Note       string  `validate:"max=500"`
+	CategoryId int32   `validate:"required,category"`
+	Frequency  int     `validate:"oneof=0 1 2"`
+	// Interval is 1 when not set.
+	Interval int `validate:"min=1"`
+	// DayOfMonth of monthly rules is the day of StartDate when not set.
+	DayOfMonth    int       `validate:"min=1,max=31"`
+	StartDate     time.Time `validate:"required"`
+	EndDate       *time.Time
+	Count         int        `validate:"min=0"`
+	LastGenerated *time.Time `json:"-"`
+}
+
+type RecurringRuleRemove struct {
+	RuleId int64
+}
+
+type RecurringException struct {
+	RuleId         int64     `validate:"required"`
+	OccurrenceDate time.Time `validate:"required"`
+	Skip           bool
+	Amount         *float32 `validate:"finite,min=-1000000000,max=1000000000"`
+	Note           *string  `validate:"max=500"`
+	CategoryId     *int32   `validate:"category"`
+}
+
+type RecurringPreviewRequest struct {
+	RuleId int64
+	Count  int
+}
+
+type RecurringOccurrence struct {
+	Date        time.Time
+	Skipped     bool
+	Transaction Transaction
+}
+
+func (rule *RecurringRule) Check() []FieldError {
+	if rule.EndDate != nil && rule.EndDate.Before(rule.StartDate) {
+		return []FieldError{{Field: "EndDate", Message: "must not be before StartDate"}}
+	}
+	return nil
+}
+
+// Occurrences returns the dates of the rule that fall into [from, to].
+// Count is applied from the very first occurrence of the rule, so the same
+// date sequence is produced no matter which window is requested.
+func (rule *RecurringRule) Occurrences(from, to time.Time) []time.Time {
+	occurrences := make([]time.Time, 0)
+	interval := rule.Interval
+	if interval < 1 {
+		interval = 1
+	}
+	produced := 0
+	for step := 0; ; step++ {
+		if rule.Count > 0 && produced >= rule.Count {
+			break
+		}
+		date := rule.occurrenceAt(step * interval)
+		if date.After(to) || (rule.EndDate != nil && date.After(*rule.EndDate)) {
+			break
+		}
+		if date.Before(rule.StartDate) {
+			continue
+		}
+		produced++
+		if !date.Before(from) {
+			occurrences = append(occurrences, date)
+		}
+	}
+	return occurrences
+}
+
+func (rule *RecurringRule) occurrenceAt(offset int) time.Time {
+	start := rule.StartDate
+	switch rule.Frequency {
+	case Weekly:
+		return start.AddDate(0, 0, 7*offset)
+	case Monthly:
+		day := rule.DayOfMonth
+		if day < 1 {
+			day = start.Day()
+		}
+		firstOfMonth := time.Date(start.Year(), start.Month()+time.Month(offset), 1, start.Hour(), start.Minute(), start.Second(), 0, start.Location())
+		lastDay := firstOfMonth.AddDate(0, 1, -1).Day()
+		if day > lastDay {
+			day = lastDay
+		}
+		return firstOfMonth.AddDate(0, 0, day-1)
+	default:
+		return start.AddDate(0, 0, offset)
+	}
+}
+
+func (rule *RecurringRule) TransactionFor(date time.Time, exception *RecurringException) (Transaction, bool) {
+	transaction := Transaction{
+		Amount:     rule.Amount,
+		SpentAt:    date.Format(SpentAtLayout),
+		Note:       rule.Note,
+		CategoryId: rule.CategoryId,
+	}
+	if exception == nil {
+		return transaction, false
+	}
+	if exception.Amount != nil {
+		transaction.Amount = *exception.Amount
+	}
+	if exception.Note != nil {
+		transaction.Note = *exception.Note
+	}
+	if exception.CategoryId != nil {
+		transaction.CategoryId = *exception.CategoryId
+	}
+	return transaction, exception.Skip
+}
+
+func (exceptions RecurringExceptions) Find(date time.Time) *RecurringException {
+	for idx := range exceptions {
+		if exceptions[idx].OccurrenceDate.Equal(date) {
+			return &exceptions[idx]
+		}
+	}
+	return nil
+}
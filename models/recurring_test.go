@@ -0,0 +1,83 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 9, 0, 0, 0, time.UTC)
+}
+
+func sameDates(t *testing.T, got []time.Time, want ...time.Time) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for idx := range got {
+		if !got[idx].Equal(want[idx]) {
+			t.Errorf("occurrence %d is %s, want %s", idx, got[idx].Format(SpentAtLayout), want[idx].Format(SpentAtLayout))
+		}
+	}
+}
+
+func TestOccurrencesKeepMonthEnd(t *testing.T) {
+	rule := RecurringRule{Frequency: Monthly, StartDate: date(2024, time.January, 31)}
+	sameDates(t, rule.Occurrences(date(2024, time.January, 1), date(2024, time.May, 1)),
+		date(2024, time.January, 31), date(2024, time.February, 29), date(2024, time.March, 31), date(2024, time.April, 30))
+
+	rule = RecurringRule{Frequency: Monthly, DayOfMonth: 30, StartDate: date(2023, time.January, 5)}
+	sameDates(t, rule.Occurrences(date(2023, time.February, 1), date(2023, time.March, 31)),
+		date(2023, time.February, 28), date(2023, time.March, 30))
+}
+
+func TestOccurrencesFollowInterval(t *testing.T) {
+	rule := RecurringRule{Frequency: Weekly, Interval: 2, StartDate: date(2024, time.March, 4)}
+	sameDates(t, rule.Occurrences(date(2024, time.March, 1), date(2024, time.April, 1)),
+		date(2024, time.March, 4), date(2024, time.March, 18), date(2024, time.April, 1))
+
+	rule = RecurringRule{Frequency: Monthly, Interval: 3, StartDate: date(2024, time.January, 15)}
+	sameDates(t, rule.Occurrences(date(2024, time.January, 1), date(2024, time.December, 31)),
+		date(2024, time.January, 15), date(2024, time.April, 15), date(2024, time.July, 15), date(2024, time.October, 15))
+
+	rule = RecurringRule{StartDate: date(2024, time.March, 30)}
+	sameDates(t, rule.Occurrences(date(2024, time.March, 1), date(2024, time.April, 1)),
+		date(2024, time.March, 30), date(2024, time.March, 31), date(2024, time.April, 1))
+}
+
+func TestOccurrencesStopAtCountAndEndDate(t *testing.T) {
+	rule := RecurringRule{Frequency: Weekly, Count: 3, StartDate: date(2024, time.March, 4)}
+	sameDates(t, rule.Occurrences(date(2024, time.March, 1), date(2024, time.June, 1)),
+		date(2024, time.March, 4), date(2024, time.March, 11), date(2024, time.March, 18))
+	// The count runs from the first occurrence, not from the requested window.
+	sameDates(t, rule.Occurrences(date(2024, time.March, 12), date(2024, time.June, 1)),
+		date(2024, time.March, 18))
+
+	end := date(2024, time.March, 12)
+	rule = RecurringRule{Frequency: Weekly, StartDate: date(2024, time.March, 4), EndDate: &end}
+	sameDates(t, rule.Occurrences(date(2024, time.March, 1), date(2024, time.June, 1)),
+		date(2024, time.March, 4), date(2024, time.March, 11))
+}
+
+func TestTransactionForAppliesExceptions(t *testing.T) {
+	rule := RecurringRule{Amount: 100, Note: "rent", CategoryId: 2, Frequency: Monthly, StartDate: date(2024, time.January, 1)}
+	amount := float32(120)
+	note := "rent with heating"
+	exceptions := RecurringExceptions{
+		{OccurrenceDate: date(2024, time.February, 1), Skip: true},
+		{OccurrenceDate: date(2024, time.March, 1), Amount: &amount, Note: &note},
+	}
+	dates := rule.Occurrences(date(2024, time.January, 1), date(2024, time.March, 31))
+
+	january, skipped := rule.TransactionFor(dates[0], exceptions.Find(dates[0]))
+	if skipped || january.Amount != 100 || january.Note != "rent" || january.SpentAt != "2024-01-01T09:00:00" {
+		t.Errorf("got %+v, skipped %v", january, skipped)
+	}
+	if _, skipped = rule.TransactionFor(dates[1], exceptions.Find(dates[1])); !skipped {
+		t.Error("the skipped occurrence was generated")
+	}
+	march, skipped := rule.TransactionFor(dates[2], exceptions.Find(dates[2]))
+	if skipped || march.Amount != 120 || march.Note != note || march.CategoryId != 2 {
+		t.Errorf("got %+v, skipped %v", march, skipped)
+	}
+}
@@ -0,0 +1,83 @@
+package models
+
+import (
+	"math"
+	"time"
+)
+
+type SavingsGoals []SavingsGoal
+
+type MonthlyContributions []MonthlyContribution
+
+type SavingsGoal struct {
+	Id           int64
+	Name         string
+	TargetAmount float32
+	TargetDate   time.Time
+	CategoryId   int32
+	StartDate    time.Time
+}
+
+type SavingsGoalRequest struct {
+	GoalId int64
+}
+
+type MonthlyContribution struct {
+	Month time.Time
+	Sum   float32
+}
+
+type SavingsGoalProgress struct {
+	GoalId              int64
+	Name                string
+	TargetAmount        float32
+	Saved               float32
+	Remaining           float32
+	Percentage          float32
+	AverageMonthly      float32
+	RequiredMonthly     float32
+	ProjectedCompletion *time.Time
+	Contributions       MonthlyContributions
+}
+
+// Progress evaluates the goal from the monthly sums of its contribution category.
+// The projection assumes the user keeps saving the average monthly amount.
+func (goal *SavingsGoal) Progress(now time.Time, contributions MonthlyContributions) SavingsGoalProgress {
+	progress := SavingsGoalProgress{
+		GoalId:        goal.Id,
+		Name:          goal.Name,
+		TargetAmount:  goal.TargetAmount,
+		Contributions: contributions,
+	}
+	for _, contribution := range contributions {
+		progress.Saved += contribution.Sum
+	}
+	progress.Remaining = goal.TargetAmount - progress.Saved
+	if progress.Remaining < 0 {
+		progress.Remaining = 0
+	}
+	if goal.TargetAmount > 0 {
+		progress.Percentage = progress.Saved / goal.TargetAmount * 100
+	}
+	progress.AverageMonthly = progress.Saved / float32(monthsBetween(goal.StartDate, now))
+	if progress.Remaining == 0 {
+		progress.ProjectedCompletion = &now
+		return progress
+	}
+	progress.RequiredMonthly = progress.Remaining / float32(monthsBetween(now, goal.TargetDate))
+	if progress.AverageMonthly > 0 {
+		monthsLeft := int(math.Ceil(float64(progress.Remaining / progress.AverageMonthly)))
+		projectedCompletion := now.AddDate(0, monthsLeft, 0)
+		progress.ProjectedCompletion = &projectedCompletion
+	}
+	return progress
+}
+
+// monthsBetween counts the calendar months touched by [from, to], never less than one.
+func monthsBetween(from, to time.Time) int {
+	months := (to.Year()-from.Year())*12 + int(to.Month()-from.Month()) + 1
+	if months < 1 {
+		return 1
+	}
+	return months
+}
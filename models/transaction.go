@@ -1,5 +1,14 @@
 package models
 
+import (
+	"fmt"
+	"time"
+)
+
+// spentAtLayouts are the forms of SpentAt accepted from clients. Postgres reads all of them; the
+// one with a space is what it returns for spentat::text, so a fetched transaction can be sent back.
+var spentAtLayouts = []string{SpentAtLayout, "2006-01-02 15:04:05", "2006-01-02T15:04", time.RFC3339, "2006-01-02"}
+
 type TransactionRemove struct {
 	TransactionId int64
 }
@@ -12,8 +21,24 @@ type PagedTransactions struct {
 }
 type Transaction struct {
 	Id         int64
-	Amount     float32
-	SpentAt    string
-	Note       string
-	CategoryId int32
+	Amount     float32 `validate:"required,finite,min=-1000000000,max=1000000000"`
+	SpentAt    string  `validate:"required,timestamp"`
+	Note       string  `validate:"max=500"`
+	CategoryId int32   `validate:"required,category"`
+	Version    int32
+	// ClientId identifies an item of a bulk insert, so a retried bulk request does not insert it twice.
+	ClientId string `json:",omitempty" validate:"max=100"`
+	// ExternalId is the id of an imported transaction in the source statement, unique per user.
+	ExternalId string `json:",omitempty" validate:"max=200"`
+}
+
+// ParseSpentAt reads a SpentAt value in any of the accepted layouts.
+func ParseSpentAt(spentAt string) (time.Time, error) {
+	for _, layout := range spentAtLayouts {
+		parsed, err := time.Parse(layout, spentAt)
+		if err == nil {
+			return parsed, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%q is not a date, use %s", spentAt, SpentAtLayout)
 }
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	CreateAction  = "create"
+	UpdateAction  = "update"
+	DeleteAction  = "delete"
+	RestoreAction = "restore"
+	PurgeAction   = "purge"
+)
+
+type TransactionHistory []TransactionHistoryEntry
+
+type TransactionHistoryEntry struct {
+	Id            int64
+	TransactionId int64
+	Action        string
+	// ChangedBy is the login of the user who made the change, empty when the server made it.
+	ChangedBy string
+	ChangedAt time.Time
+	Before    *Transaction
+	After     *Transaction
+}
+
+type TransactionHistoryRequest struct {
+	TransactionId int64
+}
+
+type TransactionRestore struct {
+	HistoryId int64
+}
+
+// Snapshot is the state of the transaction the entry leads to.
+// For deletions it is the last state before the row was removed.
+func (entry *TransactionHistoryEntry) Snapshot() *Transaction {
+	if entry.After != nil {
+		return entry.After
+	}
+	return entry.Before
+}
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+)
+
+type TrashedTransactions []TrashedTransaction
+
+type TrashedTransaction struct {
+	Transaction Transaction
+	DeletedAt   time.Time
+}
+
+// TrashRequest selects trashed transactions by id, or the whole trash of the user when All is set.
+type TrashRequest struct {
+	TransactionIds []int64
+	All            bool
+}
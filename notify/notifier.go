@@ -0,0 +1,120 @@
+package notify
+
+import (
+	"fmt"
+	"spendon/models"
+	"spendon/settings"
+	"spendon/storage"
+	"time"
+)
+
+// Notifier delivers budget alerts to a single destination.
+type Notifier interface {
+	Notify(alert *models.BudgetAlert) error
+}
+
+var thresholds = []int{80, 100}
+
+// mailServer is the SMTP server alerts are sent through, nil when the settings have none.
+var mailServer *SmtpNotifier
+
+// Configure sets up the mail server from the settings. Where alerts go is chosen by each user.
+func Configure(loadedSettings *settings.Settings) {
+	mailServer = nil
+	if loadedSettings.SmtpHost != "" {
+		mailServer = &SmtpNotifier{
+			Host:     loadedSettings.SmtpHost,
+			Port:     loadedSettings.SmtpPort,
+			Username: loadedSettings.SmtpUsername,
+			Password: loadedSettings.SmtpPassword,
+			From:     loadedSettings.SmtpFrom,
+		}
+	}
+}
+
+// notifiersFor returns a notifier for every part of destination that can be delivered to.
+func notifiersFor(destination *models.AlertDestination) []Notifier {
+	var notifiers []Notifier
+	if destination.WebhookUrl != "" {
+		notifiers = append(notifiers, NewWebhookNotifier(destination.WebhookUrl))
+	}
+	if destination.Email != "" && mailServer != nil {
+		mailer := *mailServer
+		mailer.Recipients = []string{destination.Email}
+		notifiers = append(notifiers, &mailer)
+	}
+	return notifiers
+}
+
+// CheckBudgets sends an alert for every threshold the user's budgets crossed in the current period
+// to the destination the user chose; users without one are skipped. Each threshold is recorded in
+// the database first, so it fires only once per period.
+func CheckBudgets(dbLogin *models.DbLogin) {
+	destination, err := storage.GetAlertDestination(dbLogin.Id)
+	if err != nil {
+		fmt.Println("Alert destination fetching error:", err)
+		return
+	}
+	notifiers := notifiersFor(destination)
+	if len(notifiers) == 0 {
+		return
+	}
+	budgetStatuses, err := storage.GetBudgetStatuses(dbLogin.Id, time.Now().UTC())
+	if err != nil {
+		fmt.Println("Budget status fetching error:", err)
+		return
+	}
+	for _, budgetStatus := range budgetStatuses {
+		for _, threshold := range thresholds {
+			if budgetStatus.Percentage < float32(threshold) {
+				continue
+			}
+			recorded, err := storage.TryRecordBudgetAlert(budgetStatus.BudgetId, budgetStatus.PeriodStart, threshold)
+			if err != nil {
+				fmt.Println("Budget alert recording error:", err)
+				continue
+			}
+			if !recorded {
+				continue
+			}
+			alert := models.BudgetAlert{
+				UserLogin: dbLogin.Login,
+				Threshold: threshold,
+				Status:    budgetStatus,
+			}
+			if !send(notifiers, &alert) {
+				err = storage.RemoveBudgetAlert(budgetStatus.BudgetId, budgetStatus.PeriodStart, threshold)
+				if err != nil {
+					fmt.Println("Budget alert removing error:", err)
+				}
+			}
+		}
+	}
+}
+
+func send(notifiers []Notifier, alert *models.BudgetAlert) bool {
+	delivered := false
+	for _, notifier := range notifiers {
+		err := notifier.Notify(alert)
+		if err != nil {
+			fmt.Println("Budget alert delivery error:", err)
+			continue
+		}
+		delivered = true
+	}
+	return delivered
+}
+
+func formatAlert(alert *models.BudgetAlert) (string, string) {
+	subject := fmt.Sprintf("SpendOn: %d%% of the budget for category %d is spent", alert.Threshold, alert.Status.CategoryId)
+	body := fmt.Sprintf("Hi, %s!\r\n\r\nYou have spent %.2f of %.2f (%.0f%%) for category %d in the period started %s.\r\nRemaining: %.2f, projected by the end of the period: %.2f.\r\n",
+		alert.UserLogin,
+		alert.Status.Spent,
+		alert.Status.Limit,
+		alert.Status.Percentage,
+		alert.Status.CategoryId,
+		alert.Status.PeriodStart.Format("2006-01-02"),
+		alert.Status.Remaining,
+		alert.Status.Projected)
+	return subject, body
+}
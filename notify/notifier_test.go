@@ -0,0 +1,64 @@
+package notify
+
+import (
+	"errors"
+	"spendon/models"
+	"spendon/settings"
+	"testing"
+)
+
+func TestNotifiersFollowUserDestination(t *testing.T) {
+	Configure(&settings.Settings{SmtpHost: "mail.example", SmtpFrom: "alerts@spendon.example"})
+	defer Configure(&settings.Settings{})
+
+	if notifiers := notifiersFor(&models.AlertDestination{}); len(notifiers) != 0 {
+		t.Errorf("a user without a destination got %d notifiers", len(notifiers))
+	}
+
+	notifiers := notifiersFor(&models.AlertDestination{WebhookUrl: "https://hooks.example/alice", Email: "alice@example.com"})
+	if len(notifiers) != 2 {
+		t.Fatalf("got %d notifiers, want 2", len(notifiers))
+	}
+	webhook, ok := notifiers[0].(*WebhookNotifier)
+	if !ok || webhook.Url != "https://hooks.example/alice" {
+		t.Errorf("got webhook %+v", notifiers[0])
+	}
+	mailer, ok := notifiers[1].(*SmtpNotifier)
+	if !ok || mailer.Host != "mail.example" || len(mailer.Recipients) != 1 || mailer.Recipients[0] != "alice@example.com" {
+		t.Errorf("got mailer %+v", notifiers[1])
+	}
+	if len(mailServer.Recipients) != 0 {
+		t.Errorf("the shared mail server got recipients %v", mailServer.Recipients)
+	}
+}
+
+func TestEmailNeedsMailServer(t *testing.T) {
+	Configure(&settings.Settings{})
+
+	if notifiers := notifiersFor(&models.AlertDestination{Email: "alice@example.com"}); len(notifiers) != 0 {
+		t.Errorf("got %d notifiers without a mail server", len(notifiers))
+	}
+}
+
+type fakeNotifier struct {
+	err   error
+	calls int
+}
+
+func (notifier *fakeNotifier) Notify(alert *models.BudgetAlert) error {
+	notifier.calls++
+	return notifier.err
+}
+
+func TestSendReportsAnyDelivery(t *testing.T) {
+	failing, working := &fakeNotifier{err: errors.New("down")}, &fakeNotifier{}
+	if !send([]Notifier{failing, working}, &testAlert) {
+		t.Error("one delivery was reported as none")
+	}
+	if failing.calls != 1 || working.calls != 1 {
+		t.Errorf("notified %d and %d times, want once each", failing.calls, working.calls)
+	}
+	if send([]Notifier{failing}, &testAlert) {
+		t.Error("a failed delivery was reported as delivered")
+	}
+}
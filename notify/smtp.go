@@ -0,0 +1,37 @@
+package notify
+
+import (
+	"fmt"
+	"net"
+	"net/smtp"
+	"spendon/models"
+	"strings"
+)
+
+// SmtpNotifier sends the alert as a plain text email to every recipient.
+type SmtpNotifier struct {
+	Host       string
+	Port       string
+	Username   string
+	Password   string
+	From       string
+	Recipients []string
+}
+
+func (notifier *SmtpNotifier) Notify(alert *models.BudgetAlert) error {
+	port := notifier.Port
+	if port == "" {
+		port = "25"
+	}
+	var auth smtp.Auth
+	if notifier.Username != "" {
+		auth = smtp.PlainAuth("", notifier.Username, notifier.Password, notifier.Host)
+	}
+	subject, body := formatAlert(alert)
+	message := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n%s",
+		notifier.From,
+		strings.Join(notifier.Recipients, ", "),
+		subject,
+		body)
+	return smtp.SendMail(net.JoinHostPort(notifier.Host, port), auth, notifier.From, notifier.Recipients, []byte(message))
+}
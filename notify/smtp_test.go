@@ -0,0 +1,148 @@
+package notify
+
+import (
+	"bufio"
+	"encoding/base64"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+)
+
+// smtpSession is what the fake SMTP server received in one connection.
+type smtpSession struct {
+	commands []string
+	data     string
+}
+
+// fakeSmtpServer accepts a single connection and answers it like a mail server that offers
+// plain authentication and no TLS.
+func fakeSmtpServer(t *testing.T) (host, port string, sessions <-chan smtpSession) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = listener.Close()
+	})
+	received := make(chan smtpSession, 1)
+	go func() {
+		connection, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer connection.Close()
+		session := smtpSession{}
+		defer func() {
+			received <- session
+		}()
+		text := textproto.NewConn(connection)
+		reply := func(line string) bool {
+			return text.PrintfLine("%s", line) == nil
+		}
+		if !reply("220 localhost ESMTP fake") {
+			return
+		}
+		for {
+			line, err := text.ReadLine()
+			if err != nil {
+				return
+			}
+			session.commands = append(session.commands, line)
+			verb := strings.ToUpper(strings.Fields(line + " ")[0])
+			switch verb {
+			case "EHLO":
+				reply("250-localhost greets you")
+				reply("250 AUTH PLAIN")
+			case "AUTH":
+				reply("235 Authenticated")
+			case "MAIL", "RCPT", "RSET", "NOOP":
+				reply("250 OK")
+			case "DATA":
+				reply("354 End data with <CR><LF>.<CR><LF>")
+				lines, err := text.ReadDotLines()
+				if err != nil {
+					return
+				}
+				session.data = strings.Join(lines, "\n")
+				reply("250 Queued")
+			case "QUIT":
+				reply("221 Bye")
+				return
+			default:
+				reply("502 Not implemented")
+			}
+		}
+	}()
+	host, port, err = net.SplitHostPort(listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return host, port, received
+}
+
+func TestSmtpSendsAlert(t *testing.T) {
+	host, port, sessions := fakeSmtpServer(t)
+	notifier := &SmtpNotifier{
+		Host:       host,
+		Port:       port,
+		Username:   "mailer",
+		Password:   "mail-secret",
+		From:       "alerts@spendon.example",
+		Recipients: []string{"alice@example.com"},
+	}
+
+	err := notifier.Notify(&testAlert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	session := <-sessions
+	commands := strings.Join(session.commands, "\n")
+	for _, want := range []string{"MAIL FROM:<alerts@spendon.example>", "RCPT TO:<alice@example.com>", "DATA", "QUIT"} {
+		if !strings.Contains(commands, want) {
+			t.Errorf("commands do not contain %q:\n%s", want, commands)
+		}
+	}
+	credentials := ""
+	for _, command := range session.commands {
+		if strings.HasPrefix(command, "AUTH PLAIN ") {
+			decoded, _ := base64.StdEncoding.DecodeString(strings.TrimPrefix(command, "AUTH PLAIN "))
+			credentials = string(decoded)
+		}
+	}
+	if credentials != "\x00mailer\x00mail-secret" {
+		t.Errorf("got credentials %q", credentials)
+	}
+
+	message, err := textproto.NewReader(bufio.NewReader(strings.NewReader(session.data + "\n"))).ReadMIMEHeader()
+	if err != nil {
+		t.Fatalf("reading message headers: %v", err)
+	}
+	if got := message.Get("To"); got != "alice@example.com" {
+		t.Errorf("got To %q", got)
+	}
+	if got := message.Get("Subject"); got != "SpendOn: 80% of the budget for category 2 is spent" {
+		t.Errorf("got Subject %q", got)
+	}
+	for _, want := range []string{"Hi, alice!", "410.00 of 500.00 (82%)", "2023-03-01", "Remaining: 90.00"} {
+		if !strings.Contains(session.data, want) {
+			t.Errorf("message does not contain %q:\n%s", want, session.data)
+		}
+	}
+}
+
+func TestSmtpFailsWhenUnreachable(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	_ = listener.Close()
+
+	notifier := &SmtpNotifier{Host: host, Port: port, From: "alerts@spendon.example", Recipients: []string{"alice@example.com"}}
+	err = notifier.Notify(&testAlert)
+	if err == nil {
+		t.Fatal("a closed server was reported as delivered")
+	}
+}
@@ -0,0 +1,106 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"spendon/models"
+	"syscall"
+	"time"
+)
+
+var errNonPublicAddress = errors.New("webhook address is not public")
+
+// sharedAddressSpace is the carrier-grade NAT range, which net.IP does not count as private.
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// WebhookNotifier posts the alert as JSON to Url.
+type WebhookNotifier struct {
+	Url    string
+	Client *http.Client
+}
+
+// NewWebhookNotifier posts to a URL chosen by a user, so its client only connects to public
+// addresses. The check runs on every connection, after the name was resolved, so a name that
+// resolves to another address than when the URL was saved is refused too.
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	dialer := &net.Dialer{Timeout: 5 * time.Second, Control: refuseNonPublicAddress}
+	return &WebhookNotifier{
+		Url: url,
+		Client: &http.Client{
+			Timeout:   10 * time.Second,
+			Transport: &http.Transport{DialContext: dialer.DialContext},
+		},
+	}
+}
+
+func (notifier *WebhookNotifier) Notify(alert *models.BudgetAlert) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+	response, err := notifier.Client.Post(notifier.Url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = response.Body.Close()
+	}()
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", response.StatusCode)
+	}
+	return nil
+}
+
+// CheckWebhookUrl returns an error when the host of rawUrl resolves to an address that is not
+// public, such as loopback, private or link-local ones.
+func CheckWebhookUrl(ctx context.Context, rawUrl string) error {
+	parsed, err := url.Parse(rawUrl)
+	if err != nil {
+		return err
+	}
+	host := parsed.Hostname()
+	if ip := net.ParseIP(host); ip != nil {
+		if !isPublicAddress(ip) {
+			return errNonPublicAddress
+		}
+		return nil
+	}
+	addresses, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return fmt.Errorf("webhook host cannot be resolved")
+	}
+	for _, address := range addresses {
+		if !isPublicAddress(address.IP) {
+			return errNonPublicAddress
+		}
+	}
+	return nil
+}
+
+func refuseNonPublicAddress(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !isPublicAddress(ip) {
+		return errNonPublicAddress
+	}
+	return nil
+}
+
+func isPublicAddress(ip net.IP) bool {
+	if ipv4 := ip.To4(); ipv4 != nil {
+		ip = ipv4
+		if ip[0] == 0 || sharedAddressSpace.Contains(ip) {
+			return false
+		}
+	}
+	return ip.IsGlobalUnicast() && !ip.IsPrivate()
+}
@@ -0,0 +1,121 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"spendon/models"
+	"testing"
+	"time"
+)
+
+var testAlert = models.BudgetAlert{
+	UserLogin: "alice",
+	Threshold: 80,
+	Status: models.BudgetStatus{
+		BudgetId:    3,
+		CategoryId:  2,
+		Limit:       500,
+		Spent:       410,
+		Remaining:   90,
+		Percentage:  82,
+		Projected:   640,
+		PeriodStart: time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC),
+	},
+}
+
+// loopbackNotifier may reach the httptest servers, which NewWebhookNotifier refuses.
+func loopbackNotifier(url string) *WebhookNotifier {
+	return &WebhookNotifier{Url: url, Client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func TestWebhookPostsAlert(t *testing.T) {
+	received := make(chan models.BudgetAlert, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("got %s with content type %q", r.Method, r.Header.Get("Content-Type"))
+		}
+		alert := models.BudgetAlert{}
+		err := json.NewDecoder(r.Body).Decode(&alert)
+		if err != nil {
+			t.Errorf("decoding alert: %v", err)
+		}
+		received <- alert
+		rw.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	err := loopbackNotifier(server.URL).Notify(&testAlert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	alert := <-received
+	if alert.UserLogin != testAlert.UserLogin || alert.Threshold != testAlert.Threshold ||
+		alert.Status.BudgetId != testAlert.Status.BudgetId || alert.Status.Spent != testAlert.Status.Spent ||
+		!alert.Status.PeriodStart.Equal(testAlert.Status.PeriodStart) {
+		t.Errorf("got %+v, want %+v", alert, testAlert)
+	}
+}
+
+func TestWebhookFailsOnErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	err := loopbackNotifier(server.URL).Notify(&testAlert)
+	if err == nil {
+		t.Fatal("an error status was reported as delivered")
+	}
+}
+
+func TestWebhookFailsWhenUnreachable(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL
+	server.Close()
+
+	err := loopbackNotifier(url).Notify(&testAlert)
+	if err == nil {
+		t.Fatal("a closed server was reported as delivered")
+	}
+}
+
+func TestWebhookRefusesNonPublicAddress(t *testing.T) {
+	delivered := false
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		delivered = true
+	}))
+	defer server.Close()
+
+	err := NewWebhookNotifier(server.URL).Notify(&testAlert)
+	if err == nil || delivered {
+		t.Fatal("an alert was posted to a loopback address")
+	}
+}
+
+func TestCheckWebhookUrl(t *testing.T) {
+	tests := []struct {
+		url   string
+		valid bool
+	}{
+		{"https://93.184.216.34/hook", true},
+		{"http://[2606:4700::1111]/hook", true},
+		{"http://127.0.0.1:8080/hook", false},
+		{"http://10.1.2.3/hook", false},
+		{"http://192.168.0.10/hook", false},
+		{"http://169.254.169.254/latest/meta-data", false},
+		{"http://100.64.0.1/hook", false},
+		{"http://0.0.0.0/hook", false},
+		{"http://[::1]/hook", false},
+		{"http://[fd00::1]/hook", false},
+		{"http://[::ffff:127.0.0.1]/hook", false},
+		{"http://localhost/hook", false},
+	}
+	for _, test := range tests {
+		err := CheckWebhookUrl(context.Background(), test.url)
+		if (err == nil) != test.valid {
+			t.Errorf("%s: got %v, want valid %v", test.url, err, test.valid)
+		}
+	}
+}
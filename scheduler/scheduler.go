@@ -0,0 +1,102 @@
+package scheduler
+
+import (
+	"fmt"
+	"spendon/models"
+	"spendon/settings"
+	"spendon/storage"
+	"time"
+)
+
+// Start runs every background job once and then repeats them on each tick of interval.
+func Start(interval time.Duration, loadedSettings *settings.Settings) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			runJobs(loadedSettings)
+			<-ticker.C
+		}
+	}()
+}
+
+func runJobs(loadedSettings *settings.Settings) {
+	now := time.Now().UTC()
+	generateRecurringTransactions(now)
+	purgeExpiredTrash(loadedSettings.TrashRetention())
+	purgeExpiredIdempotencyKeys(loadedSettings.IdempotencyKeyTtl())
+	eraseDeletedAccounts(now)
+}
+
+func eraseDeletedAccounts(now time.Time) {
+	erased, err := storage.EraseDueAccounts(now)
+	if err != nil {
+		fmt.Println("Account erasure error:", err)
+	}
+	if erased > 0 {
+		fmt.Println("Erased deleted accounts:", erased)
+	}
+}
+
+func purgeExpiredIdempotencyKeys(ttl time.Duration) {
+	_, err := storage.PurgeExpiredIdempotencyKeys(ttl)
+	if err != nil {
+		fmt.Println("Idempotency keys purge error:", err)
+	}
+}
+
+func purgeExpiredTrash(retention time.Duration) {
+	purged, err := storage.PurgeExpiredTrash(retention)
+	if err != nil {
+		fmt.Println("Trash purge error:", err)
+		return
+	}
+	if purged > 0 {
+		fmt.Println("Purged transactions from trash:", purged)
+	}
+}
+
+// maxOccurrencesPerRun bounds the transactions one rule may generate on a tick, so a rule starting
+// far in the past catches up over several ticks instead of flooding the database at once.
+const maxOccurrencesPerRun = 100
+
+func generateRecurringTransactions(now time.Time) {
+	rules, err := storage.GetAllRecurringRules()
+	if err != nil {
+		fmt.Println("Recurring rules fetching error:", err)
+		return
+	}
+	for idx := range rules {
+		rule := &rules[idx]
+		from := rule.StartDate
+		if rule.LastGenerated != nil {
+			from = *rule.LastGenerated
+		}
+		dueDates := rule.Occurrences(from, now)
+		if len(dueDates) == 0 {
+			continue
+		}
+		if len(dueDates) > maxOccurrencesPerRun {
+			dueDates = dueDates[:maxOccurrencesPerRun]
+		}
+		exceptions, err := storage.GetRecurringExceptions(rule.Id)
+		if err != nil {
+			fmt.Println("Recurring exceptions fetching error:", err)
+			continue
+		}
+		generateOccurrences(rule, dueDates, exceptions)
+	}
+}
+
+func generateOccurrences(rule *models.RecurringRule, dueDates []time.Time, exceptions models.RecurringExceptions) {
+	for _, date := range dueDates {
+		created, err := storage.CreateRecurringOccurrence(rule, date, exceptions.Find(date))
+		if err != nil {
+			fmt.Println("Recurring transaction creation error:", err)
+			return
+		}
+		if created {
+			fmt.Println("Recurring transaction created for rule", rule.Id, "at", date.Format(models.SpentAtLayout))
+		}
+	}
+}
@@ -0,0 +1,51 @@
+// Package service holds the operations behind the HTTP API. Every version of the API calls the
+// same methods and only translates its own request and response shapes.
+package service
+
+import (
+	"errors"
+	"spendon/models"
+	"spendon/settings"
+	"spendon/storage"
+	"time"
+)
+
+type Service struct {
+	settings *settings.Settings
+}
+
+func New(loadedSettings *settings.Settings) *Service {
+	return &Service{settings: loadedSettings}
+}
+
+// Profile returns the user together with the account deletion waiting for its grace period.
+func (service *Service) Profile(dbLogin *models.DbLogin) (*models.Profile, error) {
+	currency, err := storage.GetUserCurrency(dbLogin.Id)
+	if err != nil {
+		return nil, err
+	}
+	profile := models.Profile{
+		Id:       dbLogin.Id,
+		Login:    dbLogin.Login,
+		Currency: currency,
+		IsAdmin:  service.settings.IsAdmin(dbLogin.Login),
+	}
+	deletion, err := storage.GetPendingAccountDeletion(dbLogin.Id)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		return nil, err
+	}
+	profile.PendingDeletion = deletion
+	return &profile, nil
+}
+
+func (service *Service) Categories() (models.Categories, error) {
+	return storage.GetCategories()
+}
+
+func (service *Service) CategoryStats(dbLogin *models.DbLogin, filterBatch models.FilterBatch) (models.CategoriesSummary, error) {
+	return storage.GetTransactionsSummary(dbLogin.Id, filterBatch)
+}
+
+func (service *Service) BudgetStatuses(dbLogin *models.DbLogin) (models.BudgetStatuses, error) {
+	return storage.GetBudgetStatuses(dbLogin.Id, time.Now().UTC())
+}
@@ -0,0 +1,41 @@
+package service
+
+import (
+	"spendon/models"
+	"spendon/notify"
+	"spendon/storage"
+)
+
+func (service *Service) ListTransactions(dbLogin *models.DbLogin, request *models.FilteredRequest) (models.PagedTransactions, error) {
+	return storage.GetFilteredTransactions(dbLogin.Id, request.PageNumber, request.Pagination, &request.Filters)
+}
+
+// GetTransaction returns storage.ErrNotFound for transactions of other users and the ones in the trash.
+func (service *Service) GetTransaction(dbLogin *models.DbLogin, id int64) (*models.Transaction, error) {
+	return storage.GetTransaction(id, dbLogin.Id)
+}
+
+// CreateTransaction stores the transaction, filling in its id and version, and checks the budgets it may exceed.
+func (service *Service) CreateTransaction(dbLogin *models.DbLogin, transaction *models.Transaction) error {
+	err := storage.InsertTransaction(transaction, dbLogin.Id, dbLogin.Id)
+	if err != nil {
+		return err
+	}
+	go notify.CheckBudgets(dbLogin)
+	return nil
+}
+
+// UpdateTransaction returns *storage.ConflictError when transaction.Version is not the stored one.
+func (service *Service) UpdateTransaction(dbLogin *models.DbLogin, transaction *models.Transaction) (*models.Transaction, error) {
+	updated, err := storage.UpdateTransaction(transaction, dbLogin.Id, dbLogin.Id)
+	if err != nil {
+		return nil, err
+	}
+	go notify.CheckBudgets(dbLogin)
+	return updated, nil
+}
+
+// RemoveTransaction moves the transaction to the trash.
+func (service *Service) RemoveTransaction(dbLogin *models.DbLogin, id int64) error {
+	return storage.RemoveTransaction(id, dbLogin.Id, dbLogin.Id)
+}
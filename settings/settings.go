@@ -6,12 +6,33 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type Settings struct {
 	DatabaseUrl   string
 	SigningSecret string
 	Port          string
+	// Smtp* is the mail server budget alerts are sent through, to the address each user sets.
+	SmtpHost     string
+	SmtpPort     string
+	SmtpUsername string
+	SmtpPassword string
+	SmtpFrom     string
+	// SchedulerIntervalMinutes is how often recurring transactions are generated and expired data is purged.
+	SchedulerIntervalMinutes int
+	// TrashRetentionDays is how long removed transactions stay in the trash before they are purged.
+	TrashRetentionDays int
+	// IdempotencyKeyTtlHours is how long a repeated request with the same Idempotency-Key returns the original result.
+	IdempotencyKeyTtlHours int
+	// MccCategories maps merchant category codes ("5411") or ranges ("5811-5814") to category names or ids.
+	MccCategories map[string]string
+	// AccountDeletionGraceDays is how long a user can cancel the deletion of their account before it is erased.
+	AccountDeletionGraceDays int
+	// AdminLogins may read the audit records of account deletions.
+	AdminLogins []string
 }
 
 func (settings *Settings) Serialize() []byte {
@@ -33,6 +54,43 @@ func (settings *Settings) IsValid() bool {
 	return settings.SigningSecret != ""
 }
 
+func (settings *Settings) SchedulerInterval() time.Duration {
+	if settings.SchedulerIntervalMinutes <= 0 {
+		return time.Hour
+	}
+	return time.Duration(settings.SchedulerIntervalMinutes) * time.Minute
+}
+
+func (settings *Settings) TrashRetention() time.Duration {
+	if settings.TrashRetentionDays <= 0 {
+		return 30 * 24 * time.Hour
+	}
+	return time.Duration(settings.TrashRetentionDays) * 24 * time.Hour
+}
+
+func (settings *Settings) IdempotencyKeyTtl() time.Duration {
+	if settings.IdempotencyKeyTtlHours <= 0 {
+		return 24 * time.Hour
+	}
+	return time.Duration(settings.IdempotencyKeyTtlHours) * time.Hour
+}
+
+func (settings *Settings) AccountDeletionGrace() time.Duration {
+	if settings.AccountDeletionGraceDays <= 0 {
+		return 14 * 24 * time.Hour
+	}
+	return time.Duration(settings.AccountDeletionGraceDays) * 24 * time.Hour
+}
+
+func (settings *Settings) IsAdmin(login string) bool {
+	for _, adminLogin := range settings.AdminLogins {
+		if adminLogin == login {
+			return true
+		}
+	}
+	return false
+}
+
 func LoadSettings() *Settings {
 	absolutePath, err := filepath.Abs("./settings/settings.json")
 	if err != nil {
@@ -55,6 +113,32 @@ func loadFromEnvironmentVariables() *Settings {
 		DatabaseUrl:   os.Getenv("DATABASE_URL"),
 		SigningSecret: os.Getenv("SIGNING_SECRET"),
 		Port:          os.Getenv("PORT"),
+		SmtpHost:      os.Getenv("SMTP_HOST"),
+		SmtpPort:      os.Getenv("SMTP_PORT"),
+		SmtpUsername:  os.Getenv("SMTP_USERNAME"),
+		SmtpPassword:  os.Getenv("SMTP_PASSWORD"),
+		SmtpFrom:      os.Getenv("SMTP_FROM"),
+	}
+	if schedulerIntervalMinutes, err := strconv.Atoi(os.Getenv("SCHEDULER_INTERVAL_MINUTES")); err == nil {
+		settings.SchedulerIntervalMinutes = schedulerIntervalMinutes
+	}
+	if trashRetentionDays, err := strconv.Atoi(os.Getenv("TRASH_RETENTION_DAYS")); err == nil {
+		settings.TrashRetentionDays = trashRetentionDays
+	}
+	if idempotencyKeyTtlHours, err := strconv.Atoi(os.Getenv("IDEMPOTENCY_KEY_TTL_HOURS")); err == nil {
+		settings.IdempotencyKeyTtlHours = idempotencyKeyTtlHours
+	}
+	if accountDeletionGraceDays, err := strconv.Atoi(os.Getenv("ACCOUNT_DELETION_GRACE_DAYS")); err == nil {
+		settings.AccountDeletionGraceDays = accountDeletionGraceDays
+	}
+	if adminLogins := os.Getenv("ADMIN_LOGINS"); adminLogins != "" {
+		settings.AdminLogins = strings.Split(adminLogins, ",")
+	}
+	if mccCategories := os.Getenv("MCC_CATEGORIES"); mccCategories != "" {
+		err := json.Unmarshal([]byte(mccCategories), &settings.MccCategories)
+		if err != nil {
+			fmt.Println(err)
+		}
 	}
 	return &settings
 }
@@ -0,0 +1,12 @@
+create table AccountDeletions
+(
+	Id BIGSERIAL primary key,
+	UserId INT NOT NULL,
+	RequestedAt TIMESTAMP NOT NULL DEFAULT now(),
+	ScheduledFor TIMESTAMP NOT NULL,
+	CancelledAt TIMESTAMP,
+	ErasedAt TIMESTAMP,
+	ErasedRows BIGINT
+);
+
+create unique index UX_AccountDeletions_Pending on AccountDeletions (UserId) where CancelledAt IS NULL and ErasedAt IS NULL
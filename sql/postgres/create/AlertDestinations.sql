@@ -0,0 +1,6 @@
+create table AlertDestinations
+(
+	UserId INT REFERENCES Users (Id) primary key,
+	WebhookUrl VARCHAR(2000),
+	Email VARCHAR(320)
+)
@@ -0,0 +1,8 @@
+create table BudgetAlerts
+(
+	BudgetId BIGINT REFERENCES Budgets (Id) ON DELETE CASCADE NOT NULL,
+	PeriodStart TIMESTAMP NOT NULL,
+	Threshold INT NOT NULL,
+	SentAt TIMESTAMP NOT NULL DEFAULT now(),
+	primary key (BudgetId, PeriodStart, Threshold)
+)
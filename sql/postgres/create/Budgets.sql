@@ -0,0 +1,10 @@
+create table Budgets
+(
+	Id BIGSERIAL primary key,
+	UserId INT REFERENCES Users (Id) NOT NULL,
+	CategoryId INT REFERENCES Categories (Id) NOT NULL,
+	Period INT NOT NULL,
+	Amount MONEY NOT NULL,
+	Rollover BOOLEAN NOT NULL DEFAULT FALSE,
+	unique (UserId, CategoryId, Period)
+)
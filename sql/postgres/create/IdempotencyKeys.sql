@@ -0,0 +1,9 @@
+create table IdempotencyKeys
+(
+	UserId INT REFERENCES Users (Id) NOT NULL,
+	Key VARCHAR(255) NOT NULL,
+	CreatedAt TIMESTAMP NOT NULL DEFAULT now(),
+	StatusCode INT,
+	Response TEXT,
+	primary key (UserId, Key)
+)
@@ -0,0 +1,2 @@
+alter table IdempotencyKeys
+add RequestHash VARCHAR(64)
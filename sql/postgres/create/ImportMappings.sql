@@ -0,0 +1,8 @@
+create table ImportMappings
+(
+	Id BIGSERIAL primary key,
+	UserId INT REFERENCES Users (Id) NOT NULL,
+	Name VARCHAR(100) NOT NULL,
+	Mapping TEXT NOT NULL,
+	unique (UserId, Name)
+)
@@ -0,0 +1,5 @@
+create table MonobankWebhooks
+(
+	UserId INT REFERENCES Users (Id) primary key,
+	Secret VARCHAR(64) NOT NULL unique
+)
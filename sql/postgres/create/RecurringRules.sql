@@ -0,0 +1,33 @@
+create table RecurringRules
+(
+	Id BIGSERIAL primary key,
+	UserId INT REFERENCES Users (Id) NOT NULL,
+	Amount MONEY NOT NULL,
+	Note TEXT,
+	CategoryId INT REFERENCES Categories (Id) NOT NULL,
+	Frequency INT NOT NULL,
+	Interval INT NOT NULL DEFAULT 1,
+	DayOfMonth INT NOT NULL DEFAULT 0,
+	StartDate TIMESTAMP NOT NULL,
+	EndDate TIMESTAMP,
+	Count INT NOT NULL DEFAULT 0
+);
+
+create table RecurringExceptions
+(
+	RuleId BIGINT REFERENCES RecurringRules (Id) ON DELETE CASCADE NOT NULL,
+	OccurrenceDate TIMESTAMP NOT NULL,
+	Skip BOOLEAN NOT NULL DEFAULT FALSE,
+	Amount MONEY,
+	Note TEXT,
+	CategoryId INT REFERENCES Categories (Id),
+	primary key (RuleId, OccurrenceDate)
+);
+
+create table RecurringRuns
+(
+	RuleId BIGINT REFERENCES RecurringRules (Id) ON DELETE CASCADE NOT NULL,
+	OccurrenceDate TIMESTAMP NOT NULL,
+	TransactionId BIGINT,
+	primary key (RuleId, OccurrenceDate)
+)
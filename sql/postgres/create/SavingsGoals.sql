@@ -0,0 +1,10 @@
+create table SavingsGoals
+(
+	Id BIGSERIAL primary key,
+	UserId INT REFERENCES Users (Id) NOT NULL,
+	Name VARCHAR(200) NOT NULL,
+	TargetAmount MONEY NOT NULL,
+	TargetDate TIMESTAMP NOT NULL,
+	CategoryId INT REFERENCES Categories (Id) NOT NULL,
+	StartDate TIMESTAMP NOT NULL DEFAULT now()
+)
@@ -0,0 +1,13 @@
+create table TransactionHistory
+(
+	Id BIGSERIAL primary key,
+	TransactionId BIGINT NOT NULL,
+	UserId INT REFERENCES Users (Id) NOT NULL,
+	Action VARCHAR(10) NOT NULL,
+	ChangedBy INT REFERENCES Users (Id) NOT NULL,
+	ChangedAt TIMESTAMP NOT NULL DEFAULT now(),
+	Before JSONB,
+	After JSONB
+);
+
+create index IX_TransactionHistory_TransactionId on TransactionHistory (TransactionId, UserId)
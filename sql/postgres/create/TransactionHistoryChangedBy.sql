@@ -0,0 +1,2 @@
+alter table TransactionHistory
+alter column ChangedBy drop not null
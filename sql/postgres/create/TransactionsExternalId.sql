@@ -0,0 +1,4 @@
+alter table Transactions
+add ExternalId VARCHAR(255);
+
+create unique index UX_Transactions_ExternalId on Transactions (UserId, ExternalId) where ExternalId IS NOT NULL
@@ -0,0 +1,4 @@
+alter table Transactions
+add DeletedAt TIMESTAMP;
+
+create index IX_Transactions_DeletedAt on Transactions (DeletedAt) where DeletedAt IS NOT NULL
@@ -0,0 +1,2 @@
+alter table Transactions
+add Version INT NOT NULL DEFAULT 1
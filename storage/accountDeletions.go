@@ -0,0 +1,207 @@
+package storage
+
+import (
+	"fmt"
+	"spendon/models"
+	"time"
+
+	"github.com/jackc/pgx"
+)
+
+const (
+	insertAccountDeletion     = "INSERT INTO accountdeletions (userid, scheduledfor) VALUES ($1, $2) ON CONFLICT (userid) WHERE cancelledat IS NULL and erasedat IS NULL DO NOTHING RETURNING id, userid, requestedat, scheduledfor, cancelledat, erasedat, erasedrows"
+	cancelAccountDeletion     = "UPDATE accountdeletions SET cancelledat=now() WHERE userid=$1 and cancelledat IS NULL and erasedat IS NULL"
+	selectAccountDeletions    = "SELECT id, userid, requestedat, scheduledfor, cancelledat, erasedat, erasedrows FROM accountdeletions"
+	getAllAccountDeletions    = selectAccountDeletions + " ORDER BY requestedat DESC"
+	getPendingAccountDeletion = selectAccountDeletions + " WHERE userid=$1 and cancelledat IS NULL and erasedat IS NULL"
+	getDueAccountDeletions    = selectAccountDeletions + " WHERE scheduledfor<=$1 and cancelledat IS NULL and erasedat IS NULL"
+	claimAccountDeletion      = "UPDATE accountdeletions SET erasedat=now() WHERE id=$1 and cancelledat IS NULL and erasedat IS NULL"
+	setAccountErasedRows      = "UPDATE accountdeletions SET erasedrows=$1 WHERE id=$2"
+	// forgetChangeAuthor keeps the changes the user made to transactions of other users in their
+	// history, only without the author, as the changes the server made are recorded.
+	forgetChangeAuthor = "UPDATE transactionhistory SET changedby=NULL WHERE changedby=$1"
+)
+
+// eraseAccountQueries remove everything stored for the user whose id is $1, children first.
+// Budget alerts and recurring exceptions and runs are removed by their cascading foreign keys.
+var eraseAccountQueries = []string{
+	"DELETE FROM transactionhistory WHERE userid=$1",
+	"DELETE FROM transactions WHERE userid=$1",
+	"DELETE FROM recurringrules WHERE userid=$1",
+	"DELETE FROM budgets WHERE userid=$1",
+	"DELETE FROM savingsgoals WHERE userid=$1",
+	"DELETE FROM importmappings WHERE userid=$1",
+	"DELETE FROM idempotencykeys WHERE userid=$1",
+	"DELETE FROM monobankwebhooks WHERE userid=$1",
+	"DELETE FROM alertdestinations WHERE userid=$1",
+	"DELETE FROM users WHERE id=$1",
+}
+
+// ScheduleAccountDeletion returns ErrDuplicate when the user already has a pending deletion.
+func ScheduleAccountDeletion(userId int64, scheduledFor time.Time) (*models.AccountDeletion, error) {
+	connection, err := pgx.Connect(connectionStringConfig)
+	if err != nil {
+		fmt.Println("Connection open error:", err)
+		return nil, fmt.Errorf("DB not connected")
+	}
+	defer func() {
+		err := connection.Close()
+		if err != nil {
+			fmt.Println("Connection close error:", err)
+		}
+	}()
+	deletions, err := queryAccountDeletions(connection, insertAccountDeletion, userId, scheduledFor)
+	if err != nil {
+		return nil, err
+	}
+	if len(deletions) == 0 {
+		return nil, ErrDuplicate
+	}
+	return &deletions[0], nil
+}
+
+// GetPendingAccountDeletion returns ErrNotFound when the account is not scheduled for deletion.
+func GetPendingAccountDeletion(userId int64) (*models.AccountDeletion, error) {
+	connection, err := pgx.Connect(connectionStringConfig)
+	if err != nil {
+		fmt.Println("Connection open error:", err)
+		return nil, fmt.Errorf("DB not connected")
+	}
+	defer func() {
+		err := connection.Close()
+		if err != nil {
+			fmt.Println("Connection close error:", err)
+		}
+	}()
+	deletions, err := queryAccountDeletions(connection, getPendingAccountDeletion, userId)
+	if err != nil {
+		return nil, err
+	}
+	if len(deletions) == 0 {
+		return nil, ErrNotFound
+	}
+	return &deletions[0], nil
+}
+
+func CancelAccountDeletion(userId int64) error {
+	connection, err := pgx.Connect(connectionStringConfig)
+	if err != nil {
+		fmt.Println("Connection open error:", err)
+		return fmt.Errorf("DB not connected")
+	}
+	defer func() {
+		err := connection.Close()
+		if err != nil {
+			fmt.Println("Connection close error:", err)
+		}
+	}()
+	result, err := connection.Exec(cancelAccountDeletion, userId)
+	if err != nil {
+		fmt.Println(err)
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// GetAccountDeletions returns the audit trail of all deletions for administrators.
+func GetAccountDeletions() (models.AccountDeletions, error) {
+	connection, err := pgx.Connect(connectionStringConfig)
+	if err != nil {
+		fmt.Println("Connection open error:", err)
+		return nil, fmt.Errorf("DB not connected")
+	}
+	defer func() {
+		err := connection.Close()
+		if err != nil {
+			fmt.Println("Connection close error:", err)
+		}
+	}()
+	return queryAccountDeletions(connection, getAllAccountDeletions)
+}
+
+// EraseDueAccounts erases every account whose grace period ended before now. Each account is
+// erased in its own DB transaction together with the completion of its audit record.
+func EraseDueAccounts(now time.Time) (int, error) {
+	connection, err := pgx.Connect(connectionStringConfig)
+	if err != nil {
+		fmt.Println("Connection open error:", err)
+		return 0, fmt.Errorf("DB not connected")
+	}
+	defer func() {
+		err := connection.Close()
+		if err != nil {
+			fmt.Println("Connection close error:", err)
+		}
+	}()
+	deletions, err := queryAccountDeletions(connection, getDueAccountDeletions, now)
+	if err != nil {
+		return 0, err
+	}
+	erased := 0
+	for idx := range deletions {
+		err = eraseAccount(connection, &deletions[idx])
+		if err != nil {
+			return erased, err
+		}
+		erased++
+	}
+	return erased, nil
+}
+
+func eraseAccount(connection *pgx.Conn, deletion *models.AccountDeletion) error {
+	tx, err := connection.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	// A cancellation that arrived after the due deletions were read wins.
+	result, err := tx.Exec(claimAccountDeletion, deletion.Id)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return nil
+	}
+	_, err = tx.Exec(forgetChangeAuthor, deletion.UserId)
+	if err != nil {
+		return err
+	}
+	var erasedRows int64
+	for _, query := range eraseAccountQueries {
+		result, err := tx.Exec(query, deletion.UserId)
+		if err != nil {
+			return err
+		}
+		erasedRows += result.RowsAffected()
+	}
+	_, err = tx.Exec(setAccountErasedRows, erasedRows, deletion.Id)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func queryAccountDeletions(connection *pgx.Conn, query string, args ...interface{}) (models.AccountDeletions, error) {
+	rows, err := connection.Query(query, args...)
+	if err != nil {
+		fmt.Println(err)
+		return nil, err
+	}
+	defer rows.Close()
+	deletions := make(models.AccountDeletions, 0)
+	for rows.Next() {
+		deletion := models.AccountDeletion{}
+		err := rows.Scan(&deletion.Id, &deletion.UserId, &deletion.RequestedAt, &deletion.ScheduledFor, &deletion.CancelledAt, &deletion.ErasedAt, &deletion.ErasedRows)
+		if err != nil {
+			fmt.Println(err)
+			return deletions, err
+		}
+		deletions = append(deletions, deletion)
+	}
+	return deletions, rows.Err()
+}
@@ -0,0 +1,57 @@
+package storage
+
+import (
+	"fmt"
+	"spendon/models"
+
+	"github.com/jackc/pgx"
+)
+
+const (
+	getAlertDestination    = "SELECT COALESCE(webhookurl, ''), COALESCE(email, '') FROM alertdestinations WHERE userid=$1"
+	upsertAlertDestination = "INSERT INTO alertdestinations (userid, webhookurl, email) VALUES ($1, NULLIF($2, ''), NULLIF($3, '')) " +
+		"ON CONFLICT (userid) DO UPDATE SET webhookurl=excluded.webhookurl, email=excluded.email"
+	removeAlertDestination = "DELETE FROM alertdestinations WHERE userid=$1"
+)
+
+// GetAlertDestination returns an empty destination when the user has not set one.
+func GetAlertDestination(userId int64) (*models.AlertDestination, error) {
+	destination := models.AlertDestination{}
+	connection, err := pgx.Connect(connectionStringConfig)
+	if err != nil {
+		fmt.Println("Connection open error:", err)
+		return &destination, fmt.Errorf("DB not connected")
+	}
+	defer func() {
+		err := connection.Close()
+		if err != nil {
+			fmt.Println("Connection close error:", err)
+		}
+	}()
+	err = connection.QueryRow(getAlertDestination, userId).Scan(&destination.WebhookUrl, &destination.Email)
+	if err == pgx.ErrNoRows {
+		return &destination, nil
+	}
+	return &destination, err
+}
+
+// SaveAlertDestination replaces the destination of the user; an empty one turns alerts off.
+func SaveAlertDestination(destination *models.AlertDestination, userId int64) error {
+	connection, err := pgx.Connect(connectionStringConfig)
+	if err != nil {
+		fmt.Println("Connection open error:", err)
+		return fmt.Errorf("DB not connected")
+	}
+	defer func() {
+		err := connection.Close()
+		if err != nil {
+			fmt.Println("Connection close error:", err)
+		}
+	}()
+	if destination.IsEmpty() {
+		_, err = connection.Exec(removeAlertDestination, userId)
+		return err
+	}
+	_, err = connection.Exec(upsertAlertDestination, userId, destination.WebhookUrl, destination.Email)
+	return err
+}
@@ -0,0 +1,344 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"spendon/models"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx"
+)
+
+const (
+	getRecurringRuns           = "SELECT occurrencedate, transactionid FROM recurringruns WHERE ruleid=$1 ORDER BY occurrencedate"
+	getCategoryByName          = "SELECT id FROM categories WHERE lower(name)=lower($1)"
+	getDefaultCategory         = "SELECT id FROM categories ORDER BY lower(name)=lower($1) DESC, id LIMIT 1"
+	getTransactionByExternalId = "SELECT id FROM transactions WHERE userid=$1 and externalid=$2"
+	setMissingUserCurrency     = "UPDATE users SET currency=$1 WHERE id=$2 and COALESCE(currency, '')=''"
+	restoreRecurringRule       = "INSERT INTO recurringrules (amount, note, categoryid, frequency, interval, dayofmonth, startdate, enddate, count, userid) SELECT $1::money, $2, $3, $4, $5, $6, $7, $8, $9, $10 WHERE NOT EXISTS (SELECT 1 FROM recurringrules WHERE userid=$10 and amount=$1::money and note IS NOT DISTINCT FROM $2 and categoryid=$3 and frequency=$4 and interval=$5 and startdate=$7) RETURNING id"
+	restoreRecurringException  = "INSERT INTO recurringexceptions (ruleid, occurrencedate, skip, amount, note, categoryid) VALUES ($1, $2, $3, $4, $5, $6) ON CONFLICT (ruleid, occurrencedate) DO NOTHING"
+	restoreRecurringRun        = "INSERT INTO recurringruns (ruleid, occurrencedate, transactionid) VALUES ($1, $2, $3) ON CONFLICT (ruleid, occurrencedate) DO NOTHING"
+	restoreBudget              = "INSERT INTO budgets (categoryid, period, amount, rollover, userid) VALUES ($1, $2, $3, $4, $5) ON CONFLICT (userid, categoryid, period) DO NOTHING"
+	restoreSavingsGoal         = "INSERT INTO savingsgoals (name, targetamount, targetdate, categoryid, startdate, userid) SELECT $1, $2::money, $3, $4, $5, $6 WHERE NOT EXISTS (SELECT 1 FROM savingsgoals WHERE userid=$6 and name=$1)"
+	restoreImportMapping       = "INSERT INTO importmappings (name, mapping, userid) VALUES ($1, $2, $3) ON CONFLICT (userid, name) DO NOTHING"
+	backupExternalIdPrefix     = "backup:"
+	// defaultCategoryName receives archived items whose category this instance does not have.
+	defaultCategoryName = "Others"
+)
+
+// GetBackup collects everything of the user except transactions, which are streamed into the
+// archive separately with StreamTransactions.
+func GetBackup(userId int64, login string) (*models.Backup, error) {
+	backup := models.Backup{
+		Manifest: models.BackupManifest{Version: models.BackupVersion, CreatedAt: time.Now().UTC(), Login: login},
+		Profile:  models.BackupProfile{Login: login},
+	}
+	var err error
+	backup.Profile.Currency, err = GetUserCurrency(userId)
+	if err != nil {
+		return nil, err
+	}
+	backup.Categories, err = GetCategories()
+	if err != nil {
+		return nil, err
+	}
+	rules, err := GetRecurringRules(userId)
+	if err != nil {
+		return nil, err
+	}
+	backup.RecurringRules = make([]models.BackupRecurringRule, 0, len(rules))
+	for _, rule := range rules {
+		backupRule := models.BackupRecurringRule{RecurringRule: rule}
+		backupRule.Exceptions, err = GetRecurringExceptions(rule.Id)
+		if err != nil {
+			return nil, err
+		}
+		backupRule.Runs, err = GetRecurringRuns(rule.Id)
+		if err != nil {
+			return nil, err
+		}
+		backup.RecurringRules = append(backup.RecurringRules, backupRule)
+	}
+	backup.Budgets, err = GetBudgets(userId)
+	if err != nil {
+		return nil, err
+	}
+	backup.SavingsGoals, err = GetSavingsGoals(userId)
+	if err != nil {
+		return nil, err
+	}
+	backup.ImportMappings, err = GetImportMappings(userId)
+	if err != nil {
+		return nil, err
+	}
+	return &backup, nil
+}
+
+func GetRecurringRuns(ruleId int64) (models.RecurringRuns, error) {
+	connection, err := pgx.Connect(connectionStringConfig)
+	if err != nil {
+		fmt.Println("Connection open error:", err)
+		return nil, fmt.Errorf("DB not connected")
+	}
+	defer func() {
+		err := connection.Close()
+		if err != nil {
+			fmt.Println("Connection close error:", err)
+		}
+	}()
+	rows, err := connection.Query(getRecurringRuns, ruleId)
+	if err != nil {
+		fmt.Println(err)
+		return nil, err
+	}
+	defer rows.Close()
+	runs := make(models.RecurringRuns, 0)
+	for rows.Next() {
+		run := models.RecurringRun{}
+		err := rows.Scan(&run.OccurrenceDate, &run.TransactionId)
+		if err != nil {
+			fmt.Println(err)
+			return runs, err
+		}
+		runs = append(runs, run)
+	}
+	return runs, rows.Err()
+}
+
+// RestoreBackup adds the archived data to the user's account in one DB transaction. Categories
+// are matched by name, all other ids are remapped to the new rows. Items the account already has
+// are skipped, so restoring the same archive twice is harmless. An item referring to a category
+// the archive does not list fails the restore with a *models.FieldError naming it.
+func RestoreBackup(backup *models.Backup, userId int64) (*models.RestoreResult, error) {
+	connection, err := pgx.Connect(connectionStringConfig)
+	if err != nil {
+		fmt.Println("Connection open error:", err)
+		return nil, fmt.Errorf("DB not connected")
+	}
+	defer func() {
+		err := connection.Close()
+		if err != nil {
+			fmt.Println("Connection close error:", err)
+		}
+	}()
+	tx, err := connection.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	result := models.RestoreResult{}
+	if backup.Profile.Currency != "" {
+		_, err = tx.Exec(setMissingUserCurrency, strings.ToUpper(backup.Profile.Currency), userId)
+		if err != nil {
+			return nil, err
+		}
+	}
+	categories, err := restoreCategories(tx, backup.Categories, &result.Categories)
+	if err != nil {
+		return nil, err
+	}
+	transactions, err := restoreTransactions(tx, backup, categories, userId, &result.Transactions)
+	if err != nil {
+		return nil, err
+	}
+	err = restoreRecurringRules(tx, backup.RecurringRules, categories, transactions, userId, &result.RecurringRules)
+	if err != nil {
+		return nil, err
+	}
+	for idx, budget := range backup.Budgets {
+		categoryId, err := categoryOf(categories, budget.CategoryId, fmt.Sprintf("budgets.json[%d].CategoryId", idx))
+		if err != nil {
+			return nil, err
+		}
+		commandTag, err := tx.Exec(restoreBudget, categoryId, budget.Period, fmt.Sprintf("$%f", budget.Amount), budget.Rollover, userId)
+		if err != nil {
+			return nil, err
+		}
+		countRestored(&result.Budgets, commandTag.RowsAffected())
+	}
+	for idx, goal := range backup.SavingsGoals {
+		categoryId, err := categoryOf(categories, goal.CategoryId, fmt.Sprintf("goals.json[%d].CategoryId", idx))
+		if err != nil {
+			return nil, err
+		}
+		commandTag, err := tx.Exec(restoreSavingsGoal, goal.Name, fmt.Sprintf("$%f", goal.TargetAmount), goal.TargetDate, categoryId, goal.StartDate, userId)
+		if err != nil {
+			return nil, err
+		}
+		countRestored(&result.SavingsGoals, commandTag.RowsAffected())
+	}
+	for idx, savedMapping := range backup.ImportMappings {
+		mapping, err := remapImportMapping(&savedMapping.Mapping, categories, fmt.Sprintf("importmappings.json[%d].Mapping.DefaultCategoryId", idx))
+		if err != nil {
+			return nil, err
+		}
+		commandTag, err := tx.Exec(restoreImportMapping, savedMapping.Name, mapping, userId)
+		if err != nil {
+			return nil, err
+		}
+		countRestored(&result.ImportMappings, commandTag.RowsAffected())
+	}
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// restoreCategories returns the ids of this instance by archived category id. Categories are shared
+// by every user, so missing ones are mapped to the default category instead of being created.
+func restoreCategories(tx *pgx.Tx, categories models.Categories, count *models.RestoreCategoryCount) (map[int32]int32, error) {
+	ids := make(map[int32]int32, len(categories))
+	var defaultId int32
+	for _, category := range categories {
+		var id int32
+		err := tx.QueryRow(getCategoryByName, category.Name).Scan(&id)
+		if err == pgx.ErrNoRows {
+			if defaultId == 0 {
+				err = tx.QueryRow(getDefaultCategory, defaultCategoryName).Scan(&defaultId)
+				if err != nil {
+					return nil, err
+				}
+			}
+			id = defaultId
+			count.Defaulted++
+		} else if err != nil {
+			return nil, err
+		} else {
+			count.Matched++
+		}
+		ids[category.Id] = id
+	}
+	return ids, nil
+}
+
+// categoryOf maps an archived category id, field names the item for the error.
+func categoryOf(categories map[int32]int32, archivedId int32, field string) (int32, error) {
+	id, ok := categories[archivedId]
+	if !ok {
+		return 0, &models.FieldError{Field: field, Message: fmt.Sprintf("category %d is not in the archive", archivedId)}
+	}
+	return id, nil
+}
+
+// restoreTransactions returns the new transaction ids by archived id. Transactions without an
+// external id get one derived from the archive, so a second restore finds them as duplicates.
+func restoreTransactions(tx *pgx.Tx, backup *models.Backup, categories map[int32]int32, userId int64, count *models.RestoreCount) (map[int64]int64, error) {
+	ids := make(map[int64]int64, len(backup.Transactions))
+	for idx, transaction := range backup.Transactions {
+		archivedId := transaction.Id
+		categoryId, err := categoryOf(categories, transaction.CategoryId, fmt.Sprintf("transactions.json[%d].CategoryId", idx))
+		if err != nil {
+			return nil, err
+		}
+		transaction.CategoryId = categoryId
+		if transaction.ExternalId == "" {
+			transaction.ExternalId = fmt.Sprintf("%s%s:%d", backupExternalIdPrefix, backup.Manifest.Login, archivedId)
+		}
+		created, err := insertTransactionTx(tx, &transaction, userId, userId)
+		if errors.Is(err, ErrDuplicate) {
+			var id int64
+			err = tx.QueryRow(getTransactionByExternalId, userId, transaction.ExternalId).Scan(&id)
+			if err != nil {
+				return nil, err
+			}
+			ids[archivedId] = id
+			count.Skipped++
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		ids[archivedId] = created.Id
+		count.Inserted++
+	}
+	return ids, nil
+}
+
+// restoreRecurringRules restores the runs of every rule too, so the scheduler does not generate
+// occurrences again whose transactions were restored above.
+func restoreRecurringRules(tx *pgx.Tx, rules []models.BackupRecurringRule, categories map[int32]int32, transactions map[int64]int64, userId int64, count *models.RestoreCount) error {
+	for idx, rule := range rules {
+		categoryId, err := categoryOf(categories, rule.CategoryId, fmt.Sprintf("recurring.json[%d].CategoryId", idx))
+		if err != nil {
+			return err
+		}
+		var ruleId int64
+		err = tx.QueryRow(restoreRecurringRule,
+			fmt.Sprintf("$%f", rule.Amount),
+			rule.Note,
+			categoryId,
+			rule.Frequency,
+			rule.Interval,
+			rule.DayOfMonth,
+			rule.StartDate,
+			rule.EndDate,
+			rule.Count,
+			userId).Scan(&ruleId)
+		if err == pgx.ErrNoRows {
+			count.Skipped++
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		count.Inserted++
+		for exceptionIdx, exception := range rule.Exceptions {
+			var amount *string
+			if exception.Amount != nil {
+				formattedAmount := fmt.Sprintf("$%f", *exception.Amount)
+				amount = &formattedAmount
+			}
+			var exceptionCategoryId *int32
+			if exception.CategoryId != nil {
+				id, err := categoryOf(categories, *exception.CategoryId, fmt.Sprintf("recurring.json[%d].Exceptions[%d].CategoryId", idx, exceptionIdx))
+				if err != nil {
+					return err
+				}
+				exceptionCategoryId = &id
+			}
+			_, err = tx.Exec(restoreRecurringException, ruleId, exception.OccurrenceDate, exception.Skip, amount, exception.Note, exceptionCategoryId)
+			if err != nil {
+				return err
+			}
+		}
+		for _, run := range rule.Runs {
+			var transactionId *int64
+			if run.TransactionId != nil {
+				if id, ok := transactions[*run.TransactionId]; ok {
+					transactionId = &id
+				}
+			}
+			_, err = tx.Exec(restoreRecurringRun, ruleId, run.OccurrenceDate, transactionId)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func remapImportMapping(mapping *models.ImportMapping, categories map[int32]int32, field string) (string, error) {
+	remapped := *mapping
+	if remapped.DefaultCategoryId != 0 {
+		categoryId, err := categoryOf(categories, remapped.DefaultCategoryId, field)
+		if err != nil {
+			return "", err
+		}
+		remapped.DefaultCategoryId = categoryId
+	}
+	encoded, err := json.Marshal(remapped)
+	return string(encoded), err
+}
+
+func countRestored(count *models.RestoreCount, rowsAffected int64) {
+	if rowsAffected == 0 {
+		count.Skipped++
+	} else {
+		count.Inserted++
+	}
+}
@@ -0,0 +1,178 @@
+package storage
+
+import (
+	"fmt"
+	"spendon/models"
+	"time"
+
+	"github.com/jackc/pgx"
+)
+
+const (
+	insertBudget      = "INSERT INTO budgets (categoryid, period, amount, rollover, userid) VALUES ($1, $2, $3, $4, $5) ON CONFLICT (userid, categoryid, period) DO UPDATE SET amount=excluded.amount, rollover=excluded.rollover RETURNING id"
+	getBudgetsForUser = "SELECT id, categoryid, period, amount::numeric, rollover FROM budgets WHERE userid=$1 ORDER BY id"
+	removeBudget      = "DELETE FROM budgets WHERE id=$1 and userid=$2"
+	insertBudgetAlert = "INSERT INTO budgetalerts (budgetid, periodstart, threshold) VALUES ($1, $2, $3) ON CONFLICT (budgetid, periodstart, threshold) DO NOTHING"
+	removeBudgetAlert = "DELETE FROM budgetalerts WHERE budgetid=$1 and periodstart=$2 and threshold=$3"
+)
+
+func InsertBudget(budget *models.Budget, userId int64) error {
+	connection, err := pgx.Connect(connectionStringConfig)
+	if err != nil {
+		fmt.Println("Connection open error:", err)
+		return fmt.Errorf("DB not connected")
+	}
+	defer func() {
+		err := connection.Close()
+		if err != nil {
+			fmt.Println("Connection close error:", err)
+		}
+	}()
+	err = connection.QueryRow(insertBudget,
+		budget.CategoryId,
+		budget.Period,
+		fmt.Sprintf("$%f", budget.Amount),
+		budget.Rollover,
+		userId).Scan(&budget.Id)
+	if err != nil {
+		fmt.Println(err)
+		return err
+	}
+	return publishEvent(connection, &models.Event{Type: models.BudgetChangedEvent, UserId: userId, Budget: budget})
+}
+
+func GetBudgets(userId int64) (models.Budgets, error) {
+	connection, err := pgx.Connect(connectionStringConfig)
+	if err != nil {
+		fmt.Println("Connection open error:", err)
+		return nil, fmt.Errorf("DB not connected")
+	}
+	defer func() {
+		err := connection.Close()
+		if err != nil {
+			fmt.Println("Connection close error:", err)
+		}
+	}()
+	rows, err := connection.Query(getBudgetsForUser, userId)
+	if err != nil {
+		fmt.Println(err)
+		return nil, err
+	}
+	defer rows.Close()
+	budgets := make(models.Budgets, 0)
+	for rows.Next() {
+		budget := models.Budget{}
+		err := rows.Scan(&budget.Id, &budget.CategoryId, &budget.Period, &budget.Amount, &budget.Rollover)
+		if err != nil {
+			fmt.Println(err)
+			return budgets, err
+		}
+		budgets = append(budgets, budget)
+	}
+	return budgets, rows.Err()
+}
+
+// RemoveBudget returns ErrNotFound when the user has no such budget.
+func RemoveBudget(budgetId, userId int64) error {
+	connection, err := pgx.Connect(connectionStringConfig)
+	if err != nil {
+		fmt.Println("Connection open error:", err)
+		return fmt.Errorf("DB not connected")
+	}
+	defer func() {
+		err := connection.Close()
+		if err != nil {
+			fmt.Println("Connection close error:", err)
+		}
+	}()
+	result, err := connection.Exec(removeBudget, budgetId, userId)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return publishEvent(connection, &models.Event{Type: models.BudgetRemovedEvent, UserId: userId, BudgetId: budgetId})
+}
+
+// GetBudgetStatuses aggregates spending of every budget of the user with GetTransactionsSummary.
+func GetBudgetStatuses(userId int64, at time.Time) (models.BudgetStatuses, error) {
+	budgets, err := GetBudgets(userId)
+	if err != nil {
+		return nil, err
+	}
+	statuses := make(models.BudgetStatuses, 0, len(budgets))
+	for idx := range budgets {
+		status, err := GetBudgetStatus(userId, &budgets[idx], at)
+		if err != nil {
+			return statuses, err
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+func GetBudgetStatus(userId int64, budget *models.Budget, at time.Time) (models.BudgetStatus, error) {
+	start, end := budget.PeriodBounds(at)
+	spent, err := sumCategorySpending(userId, budget.Filters(start, end))
+	if err != nil {
+		return models.BudgetStatus{}, err
+	}
+	var previousSpent float32
+	if budget.Rollover {
+		previousStart, _ := budget.PeriodBounds(start.Add(-time.Nanosecond))
+		previousSpent, err = sumCategorySpending(userId, budget.Filters(previousStart, start))
+		if err != nil {
+			return models.BudgetStatus{}, err
+		}
+	}
+	return budget.Status(at, spent, previousSpent), nil
+}
+
+func sumCategorySpending(userId int64, filterBatch models.FilterBatch) (float32, error) {
+	categoriesSummary, err := GetTransactionsSummary(userId, filterBatch)
+	if err != nil {
+		return 0, err
+	}
+	var sum float32
+	for _, categorySummary := range categoriesSummary {
+		sum += categorySummary.Sum
+	}
+	return sum, nil
+}
+
+// TryRecordBudgetAlert returns true only for the first call with the same budget, period and threshold.
+func TryRecordBudgetAlert(budgetId int64, periodStart time.Time, threshold int) (bool, error) {
+	connection, err := pgx.Connect(connectionStringConfig)
+	if err != nil {
+		fmt.Println("Connection open error:", err)
+		return false, fmt.Errorf("DB not connected")
+	}
+	defer func() {
+		err := connection.Close()
+		if err != nil {
+			fmt.Println("Connection close error:", err)
+		}
+	}()
+	result, err := connection.Exec(insertBudgetAlert, budgetId, periodStart, threshold)
+	if err != nil {
+		return false, err
+	}
+	return result.RowsAffected() == 1, nil
+}
+
+func RemoveBudgetAlert(budgetId int64, periodStart time.Time, threshold int) error {
+	connection, err := pgx.Connect(connectionStringConfig)
+	if err != nil {
+		fmt.Println("Connection open error:", err)
+		return fmt.Errorf("DB not connected")
+	}
+	defer func() {
+		err := connection.Close()
+		if err != nil {
+			fmt.Println("Connection close error:", err)
+		}
+	}()
+	_, err = connection.Exec(removeBudgetAlert, budgetId, periodStart, threshold)
+	return err
+}
@@ -5,19 +5,24 @@ import (
 	"fmt"
 	"github.com/jackc/pgx"
 	"spendon/models"
+	"strings"
 )
 
 const (
-	insertTransaction           = "INSERT INTO transactions (amount, spentat, note, categoryid, userid) VALUES ($1, $2, $3, $4, $5)"
+	insertTransaction           = "INSERT INTO transactions (amount, spentat, note, categoryid, userid, externalid) VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''))"
+	insertReturningTransaction  = insertTransaction + " ON CONFLICT DO NOTHING RETURNING id, version"
 	insertUser                  = "IF NOT EXISTS (select * from dbo.Users where login=$1) THEN INSERT INTO users (login, passwordhash, currency) VALUES($1, $2, $3) END IF"
 	selectCategories            = "SELECT * FROM categories"
-	updateTransaction           = "UPDATE transactions SET amount=$1, spentat=$2, note=$3, categoryid=$4 where id=$5 and userid=$6"
-	removeTransaction           = "DELETE FROM transactions WHERE id=$1 and userid=$2"
-	getPaginatedTransactions    = "SELECT id, amount::numeric, spentat::text, note, categoryid FROM transactions WHERE %s userId=$%d ORDER BY spentat DESC OFFSET $%d ROWS FETCH NEXT $%d ROWS ONLY"
+	updateTransaction           = "UPDATE transactions SET amount=$1, spentat=$2, note=$3, categoryid=$4, version=version+1 where id=$5 and userid=$6"
+	updateReturningTransaction  = updateTransaction + " RETURNING id, amount::numeric, spentat::text, note, categoryid, version"
+	removeTransaction           = "UPDATE transactions SET deletedat=now() WHERE id=$1 and userid=$2 and deletedat IS NULL"
+	getTransactionById          = "SELECT id, amount::numeric, spentat::text, note, categoryid, version FROM transactions WHERE id=$1 and userid=$2 and deletedat IS NULL"
+	getPaginatedTransactions    = "SELECT id, amount::numeric, spentat::text, note, categoryid, version FROM transactions WHERE %s deletedat IS NULL and userId=$%d ORDER BY spentat DESC OFFSET $%d ROWS FETCH NEXT $%d ROWS ONLY"
 	getUserByPassword           = "SELECT id, login from users WHERE login=$1 and passwordhash=$2"
 	getUserByLogin              = "SELECT id, login from users WHERE login=$1"
-	getStatistics               = "SELECT categoryid , SUM(amount)::numeric from transactions where %s userid=$%d GROUP BY categoryid"
-	getTransactionsCountForUser = "SELECT COUNT(*) as cnt FROM transactions WHERE %s userid=$%d"
+	getUserCurrency             = "SELECT COALESCE(currency, '') from users WHERE id=$1"
+	getStatistics               = "SELECT categoryid , SUM(amount)::numeric from transactions where %s deletedat IS NULL and userid=$%d GROUP BY categoryid"
+	getTransactionsCountForUser = "SELECT COUNT(*) as cnt FROM transactions WHERE %s deletedat IS NULL and userid=$%d"
 )
 
 var connectionStringConfig pgx.ConnConfig
@@ -30,7 +35,7 @@ func InitializeSettings(connectionUrl string) {
 	connectionStringConfig = connStr
 }
 
-func InsertTransaction(transaction *models.Transaction, userId int64) error {
+func InsertTransaction(transaction *models.Transaction, userId, changedBy int64) error {
 	connection, err := pgx.Connect(connectionStringConfig)
 	if err != nil {
 		fmt.Println("Connection open error:", err)
@@ -42,18 +47,26 @@ func InsertTransaction(transaction *models.Transaction, userId int64) error {
 			fmt.Println("Connection close error:", err)
 		}
 	}()
-	rslt, err := connection.Exec(insertTransaction,
-		fmt.Sprintf("$%f", transaction.Amount),
-		transaction.SpentAt,
-		transaction.Note,
-		transaction.CategoryId,
-		userId)
+	tx, err := connection.Begin()
+	if err != nil {
+		fmt.Println(err)
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	created, err := insertTransactionTx(tx, transaction, userId, changedBy)
 	if err != nil {
 		fmt.Println(err)
 		return err
 	}
-	rowsAffectedCount := rslt.RowsAffected()
-	fmt.Println("Rows affected:", rowsAffectedCount)
+	err = tx.Commit()
+	if err != nil {
+		fmt.Println(err)
+		return err
+	}
+	*transaction = *created
+	fmt.Println("Inserted transaction:", created.Id)
 	return nil
 }
 
@@ -88,7 +101,33 @@ func GetCategories() (models.Categories, error) {
 	return categories, err
 }
 
-func UpdateTransaction(transaction *models.Transaction, userId int64) (*models.Transaction, error) {
+// GetTransaction returns ErrNotFound for transactions of other users and the ones in the trash.
+func GetTransaction(id, userId int64) (*models.Transaction, error) {
+	connection, err := pgx.Connect(connectionStringConfig)
+	if err != nil {
+		fmt.Println("Connection open error:", err)
+		return nil, fmt.Errorf("DB not connected")
+	}
+	defer func() {
+		err := connection.Close()
+		if err != nil {
+			fmt.Println("Connection close error:", err)
+		}
+	}()
+	transaction := models.Transaction{}
+	err = connection.QueryRow(getTransactionById, id, userId).Scan(&transaction.Id, &transaction.Amount, &transaction.SpentAt, &transaction.Note, &transaction.CategoryId, &transaction.Version)
+	if err == pgx.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &transaction, nil
+}
+
+// UpdateTransaction applies the change only when transaction.Version matches the stored version
+// and returns the row as it is stored after the update.
+func UpdateTransaction(transaction *models.Transaction, userId, changedBy int64) (*models.Transaction, error) {
 	connection, err := pgx.Connect(connectionStringConfig)
 	if err != nil {
 		fmt.Println("Connection open error:", err)
@@ -100,22 +139,51 @@ func UpdateTransaction(transaction *models.Transaction, userId int64) (*models.T
 			fmt.Println("Connection close error:", err)
 		}
 	}()
-	result, err := connection.Exec(updateTransaction,
+	tx, err := connection.Begin()
+	if err != nil {
+		return &models.Transaction{}, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	before, err := lockTransaction(tx, transaction.Id, userId)
+	if err != nil {
+		return &models.Transaction{}, err
+	}
+	if before == nil {
+		return &models.Transaction{}, ErrNotFound
+	}
+	if before.Version != transaction.Version {
+		return &models.Transaction{}, &ConflictError{Current: before}
+	}
+	stored := models.Transaction{}
+	err = tx.QueryRow(updateReturningTransaction,
 		transaction.Amount,
 		transaction.SpentAt,
 		transaction.Note,
 		transaction.CategoryId,
 		transaction.Id,
-		userId)
+		userId).Scan(&stored.Id, &stored.Amount, &stored.SpentAt, &stored.Note, &stored.CategoryId, &stored.Version)
+	if err == pgx.ErrNoRows {
+		return &models.Transaction{}, ErrNotFound
+	}
 	if err != nil {
 		fmt.Println(err)
 		return &models.Transaction{}, err
 	}
-	fmt.Println("Update result:", result.RowsAffected())
-	return transaction, nil
+	err = recordHistory(tx, stored.Id, userId, changedBy, models.UpdateAction, before, &stored)
+	if err != nil {
+		return &models.Transaction{}, err
+	}
+	err = tx.Commit()
+	if err != nil {
+		return &models.Transaction{}, err
+	}
+	return &stored, nil
 }
 
-func RemoveTransaction(id, userId int64) error {
+// RemoveTransaction returns ErrNotFound when the user has no such transaction outside of the trash.
+func RemoveTransaction(id, userId, changedBy int64) error {
 	connection, err := pgx.Connect(connectionStringConfig)
 	if err != nil {
 		fmt.Println("Connection open error:", err)
@@ -127,14 +195,31 @@ func RemoveTransaction(id, userId int64) error {
 			fmt.Println("Connection close error:", err)
 		}
 	}()
-	result, err := connection.Exec(removeTransaction,
+	tx, err := connection.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	before, err := lockTransaction(tx, id, userId)
+	if err != nil {
+		return err
+	}
+	result, err := tx.Exec(removeTransaction,
 		id,
 		userId)
 	if err != nil {
 		return err
 	}
-	fmt.Println("Delete result:", result.RowsAffected())
-	return nil
+	if before == nil || result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	err = recordHistory(tx, id, userId, changedBy, models.DeleteAction, before, nil)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 func GetUserByPassword(password, login string) (*models.DbLogin, error) {
@@ -156,10 +241,9 @@ func GetUserByPassword(password, login string) (*models.DbLogin, error) {
 		login,
 		pwdHashString)
 	err = row.Scan(&dbLogin.Id, &dbLogin.Login)
-	if err != nil {
-		return &dbLogin, err
+	if err == pgx.ErrNoRows {
+		return &dbLogin, ErrNotFound
 	}
-
 	if err != nil {
 		return &dbLogin, err
 	}
@@ -182,14 +266,34 @@ func GetUserByLogin(login string) (*models.DbLogin, error) {
 	row := connection.QueryRow(getUserByLogin, login)
 
 	err = row.Scan(&dbLogin.Id, &dbLogin.Login)
+	if err == pgx.ErrNoRows {
+		return &dbLogin, ErrNotFound
+	}
 	if err != nil {
 		return &dbLogin, err
 	}
+	return &dbLogin, nil
+}
 
+// GetUserCurrency returns the currency the user registered with, empty when none was set.
+func GetUserCurrency(userId int64) (string, error) {
+	connection, err := pgx.Connect(connectionStringConfig)
 	if err != nil {
-		return &dbLogin, err
+		fmt.Println("Connection open error:", err)
+		return "", fmt.Errorf("DB not connected")
 	}
-	return &dbLogin, nil
+	defer func() {
+		err := connection.Close()
+		if err != nil {
+			fmt.Println("Connection close error:", err)
+		}
+	}()
+	currency := ""
+	err = connection.QueryRow(getUserCurrency, userId).Scan(&currency)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(currency), nil
 }
 
 func GetFilteredTransactions(userId, pageNumber, pagination int64, filterBatch *models.FilterBatch) (models.PagedTransactions, error) {
@@ -239,7 +343,7 @@ func GetFilteredTransactions(userId, pageNumber, pagination int64, filterBatch *
 	transactions := make([]models.Transaction, 0)
 	for rows.Next() {
 		transaction := models.Transaction{}
-		err := rows.Scan(&transaction.Id, &transaction.Amount, &transaction.SpentAt, &transaction.Note, &transaction.CategoryId)
+		err := rows.Scan(&transaction.Id, &transaction.Amount, &transaction.SpentAt, &transaction.Note, &transaction.CategoryId, &transaction.Version)
 		if err != nil {
 			fmt.Println(err)
 			return models.PagedTransactions{}, err
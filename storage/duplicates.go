@@ -0,0 +1,123 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"spendon/models"
+
+	"github.com/jackc/pgx"
+)
+
+const (
+	getDuplicatePairs = "SELECT a.id, a.amount::numeric, a.spentat::text, a.note, a.categoryid, a.version, b.id, b.amount::numeric, b.spentat::text, b.note, b.categoryid, b.version " +
+		"FROM transactions a JOIN transactions b ON b.userid=a.userid and b.id>a.id and b.amount=a.amount and b.deletedat IS NULL and abs(extract(epoch FROM b.spentat-a.spentat))<=$2 " +
+		"WHERE a.userid=$1 and a.deletedat IS NULL"
+	setTransactionNote = "UPDATE transactions SET note=$1, version=version+1 WHERE id=$2 and userid=$3 RETURNING id, amount::numeric, spentat::text, note, categoryid, version"
+)
+
+// GetDuplicateCandidates pairs transactions of the same amount spent within daysWindow days
+// and returns the pairs scored at least minConfidence, most likely duplicates first.
+func GetDuplicateCandidates(userId int64, daysWindow int, minConfidence float32) (models.DuplicateCandidates, error) {
+	connection, err := pgx.Connect(connectionStringConfig)
+	if err != nil {
+		fmt.Println("Connection open error:", err)
+		return nil, fmt.Errorf("DB not connected")
+	}
+	defer func() {
+		err := connection.Close()
+		if err != nil {
+			fmt.Println("Connection close error:", err)
+		}
+	}()
+	rows, err := connection.Query(getDuplicatePairs, userId, daysWindow*24*60*60)
+	if err != nil {
+		fmt.Println(err)
+		return nil, err
+	}
+	defer rows.Close()
+	candidates := make(models.DuplicateCandidates, 0)
+	for rows.Next() {
+		candidate := models.DuplicateCandidate{}
+		first, second := &candidate.First, &candidate.Second
+		err := rows.Scan(&first.Id, &first.Amount, &first.SpentAt, &first.Note, &first.CategoryId, &first.Version,
+			&second.Id, &second.Amount, &second.SpentAt, &second.Note, &second.CategoryId, &second.Version)
+		if err != nil {
+			fmt.Println(err)
+			return candidates, err
+		}
+		candidate.Confidence = models.DuplicateConfidence(first, second, daysWindow)
+		if candidate.Confidence >= minConfidence {
+			candidates = append(candidates, candidate)
+		}
+	}
+	if rows.Err() != nil {
+		return candidates, rows.Err()
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Confidence > candidates[j].Confidence
+	})
+	return candidates, nil
+}
+
+// MergeTransactions keeps one transaction of a duplicate pair and moves the other one to the trash.
+// The note of the removed transaction is kept when the remaining one has none.
+func MergeTransactions(merge *models.TransactionMerge, userId, changedBy int64) (*models.Transaction, error) {
+	connection, err := pgx.Connect(connectionStringConfig)
+	if err != nil {
+		fmt.Println("Connection open error:", err)
+		return &models.Transaction{}, fmt.Errorf("DB not connected")
+	}
+	defer func() {
+		err := connection.Close()
+		if err != nil {
+			fmt.Println("Connection close error:", err)
+		}
+	}()
+	if merge.KeepId == merge.RemoveId {
+		return &models.Transaction{}, fmt.Errorf("cannot merge a transaction with itself")
+	}
+	tx, err := connection.Begin()
+	if err != nil {
+		return &models.Transaction{}, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	kept, err := lockTransaction(tx, merge.KeepId, userId)
+	if err != nil {
+		return &models.Transaction{}, err
+	}
+	removed, err := lockTransaction(tx, merge.RemoveId, userId)
+	if err != nil {
+		return &models.Transaction{}, err
+	}
+	if kept == nil || removed == nil {
+		return &models.Transaction{}, ErrNotFound
+	}
+	result := kept
+	if kept.Note == "" && removed.Note != "" {
+		updated := models.Transaction{}
+		err = tx.QueryRow(setTransactionNote, removed.Note, kept.Id, userId).Scan(&updated.Id, &updated.Amount, &updated.SpentAt, &updated.Note, &updated.CategoryId, &updated.Version)
+		if err != nil {
+			return &models.Transaction{}, err
+		}
+		err = recordHistory(tx, kept.Id, userId, changedBy, models.UpdateAction, kept, &updated)
+		if err != nil {
+			return &models.Transaction{}, err
+		}
+		result = &updated
+	}
+	_, err = tx.Exec(removeTransaction, removed.Id, userId)
+	if err != nil {
+		return &models.Transaction{}, err
+	}
+	err = recordHistory(tx, removed.Id, userId, changedBy, models.DeleteAction, removed, nil)
+	if err != nil {
+		return &models.Transaction{}, err
+	}
+	err = tx.Commit()
+	if err != nil {
+		return &models.Transaction{}, err
+	}
+	return result, nil
+}
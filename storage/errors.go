@@ -0,0 +1,25 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"spendon/models"
+)
+
+// ErrNotFound is returned when no row matches the id for the given user.
+var ErrNotFound = errors.New("not found")
+
+// ErrDuplicate is returned when an imported transaction was already imported before.
+var ErrDuplicate = errors.New("duplicate")
+
+// ErrKeyReused is returned when an idempotency key is repeated with a different request.
+var ErrKeyReused = errors.New("idempotency key reused with a different request")
+
+// ConflictError is returned when the transaction was changed after the client has read it.
+type ConflictError struct {
+	Current *models.Transaction
+}
+
+func (err *ConflictError) Error() string {
+	return fmt.Sprintf("transaction %d was changed, current version is %d", err.Current.Id, err.Current.Version)
+}
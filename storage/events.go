@@ -0,0 +1,100 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"spendon/models"
+	"time"
+
+	"github.com/jackc/pgx"
+)
+
+const (
+	eventsChannel = "spendon_events"
+	notifyEvent   = "SELECT pg_notify('" + eventsChannel + "', $1)"
+	// listenRetryDelay is the pause before the listener connects again after losing the connection.
+	listenRetryDelay = 5 * time.Second
+)
+
+// eventPayload carries UserId, which Event hides from the clients.
+type eventPayload struct {
+	UserId int64
+	Event  models.Event
+}
+
+// executor is a connection or a DB transaction. Postgres delivers a notification sent inside a DB
+// transaction only when it commits, so a rolled back change is never announced.
+type executor interface {
+	Exec(sql string, arguments ...interface{}) (pgx.CommandTag, error)
+}
+
+func publishEvent(executor executor, event *models.Event) error {
+	payload, err := json.Marshal(eventPayload{UserId: event.UserId, Event: *event})
+	if err != nil {
+		return err
+	}
+	_, err = executor.Exec(notifyEvent, string(payload))
+	return err
+}
+
+// transactionEvent describes a change recorded in the transaction history. Purging is not sent,
+// as clients removed the transaction when it went to the trash.
+func transactionEvent(userId int64, action string, before, after *models.Transaction) *models.Event {
+	switch {
+	case action == models.PurgeAction:
+		return nil
+	case after == nil:
+		return &models.Event{Type: models.TransactionDeletedEvent, UserId: userId, TransactionId: before.Id}
+	case before == nil:
+		return &models.Event{Type: models.TransactionCreatedEvent, UserId: userId, Transaction: after}
+	}
+	return &models.Event{Type: models.TransactionUpdatedEvent, UserId: userId, Transaction: after}
+}
+
+// ListenForEvents passes the events published by every server instance to publish until ctx is
+// done. A lost connection is opened again; events sent meanwhile are lost, which subscribers
+// learn from the gap in event ids.
+func ListenForEvents(ctx context.Context, publish func(event models.Event)) {
+	for ctx.Err() == nil {
+		err := listenForEvents(ctx, publish)
+		if err != nil && ctx.Err() == nil {
+			fmt.Println("Event listener error:", err)
+			select {
+			case <-ctx.Done():
+			case <-time.After(listenRetryDelay):
+			}
+		}
+	}
+}
+
+func listenForEvents(ctx context.Context, publish func(event models.Event)) error {
+	connection, err := pgx.Connect(connectionStringConfig)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err := connection.Close()
+		if err != nil {
+			fmt.Println("Connection close error:", err)
+		}
+	}()
+	err = connection.Listen(eventsChannel)
+	if err != nil {
+		return err
+	}
+	for {
+		notification, err := connection.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		payload := eventPayload{}
+		err = json.Unmarshal([]byte(notification.Payload), &payload)
+		if err != nil {
+			fmt.Println("Event decoding error:", err)
+			continue
+		}
+		payload.Event.UserId = payload.UserId
+		publish(payload.Event)
+	}
+}
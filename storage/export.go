@@ -0,0 +1,50 @@
+package storage
+
+import (
+	"fmt"
+	"spendon/models"
+
+	"github.com/jackc/pgx"
+)
+
+const getExportTransactions = "SELECT id, amount::numeric, spentat::text, note, categoryid, version, COALESCE(externalid, '') FROM transactions WHERE %s deletedat IS NULL and userid=$%d ORDER BY spentat DESC, id DESC"
+
+// StreamTransactions calls visit for every transaction matching filterBatch, newest first.
+// Rows are read from the connection one by one, so the result is never held in memory.
+func StreamTransactions(userId int64, filterBatch *models.FilterBatch, visit func(transaction *models.Transaction) error) error {
+	connection, err := pgx.Connect(connectionStringConfig)
+	if err != nil {
+		fmt.Println("Connection open error:", err)
+		return fmt.Errorf("DB not connected")
+	}
+	defer func() {
+		err := connection.Close()
+		if err != nil {
+			fmt.Println("Connection close error:", err)
+		}
+	}()
+	filterString, args, err := filterBatch.Build()
+	if err != nil {
+		return err
+	}
+	args = append(args, userId)
+	rows, err := connection.Query(fmt.Sprintf(getExportTransactions, filterString, len(args)), args...)
+	if err != nil {
+		fmt.Println(err)
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		transaction := models.Transaction{}
+		err := rows.Scan(&transaction.Id, &transaction.Amount, &transaction.SpentAt, &transaction.Note, &transaction.CategoryId, &transaction.Version, &transaction.ExternalId)
+		if err != nil {
+			fmt.Println(err)
+			return err
+		}
+		err = visit(&transaction)
+		if err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
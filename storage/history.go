@@ -0,0 +1,242 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"spendon/models"
+
+	"github.com/jackc/pgx"
+)
+
+const (
+	insertHistoryEntry       = "INSERT INTO transactionhistory (transactionid, userid, action, changedby, before, after) VALUES ($1, $2, $3, $4, $5, $6)"
+	selectHistoryEntries     = "SELECT h.id, h.transactionid, h.action, coalesce(u.login, ''), h.changedat, h.before::text, h.after::text FROM transactionhistory h LEFT JOIN users u ON u.id=h.changedby"
+	getTransactionHistory    = selectHistoryEntries + " WHERE h.transactionid=$1 and h.userid=$2 ORDER BY h.id"
+	getHistoryEntry          = selectHistoryEntries + " WHERE h.id=$1 and h.userid=$2"
+	selectTransactionForLock = "SELECT id, amount::numeric, spentat::text, note, categoryid, version FROM transactions WHERE id=$1 and userid=$2 and deletedat IS NULL FOR UPDATE"
+	insertTransactionWithId  = "INSERT INTO transactions (id, amount, spentat, note, categoryid, userid) VALUES ($1, $2, $3, $4, $5, $6)"
+	untrashTransaction       = "UPDATE transactions SET deletedat=NULL WHERE id=$1 and userid=$2 and deletedat IS NOT NULL"
+)
+
+// serverChange is recorded as the author of the changes no user made, like transactions generated
+// by recurring rules or trash purged after its retention.
+const serverChange int64 = 0
+
+func GetTransactionHistory(transactionId, userId int64) (models.TransactionHistory, error) {
+	connection, err := pgx.Connect(connectionStringConfig)
+	if err != nil {
+		fmt.Println("Connection open error:", err)
+		return nil, fmt.Errorf("DB not connected")
+	}
+	defer func() {
+		err := connection.Close()
+		if err != nil {
+			fmt.Println("Connection close error:", err)
+		}
+	}()
+	rows, err := connection.Query(getTransactionHistory, transactionId, userId)
+	if err != nil {
+		fmt.Println(err)
+		return nil, err
+	}
+	defer rows.Close()
+	history := make(models.TransactionHistory, 0)
+	for rows.Next() {
+		entry, err := scanHistoryEntry(rows)
+		if err != nil {
+			fmt.Println(err)
+			return history, err
+		}
+		history = append(history, *entry)
+	}
+	return history, rows.Err()
+}
+
+// RestoreTransactionVersion brings the transaction back to the state recorded by the history entry.
+// A trashed transaction is taken out of the trash, a purged one is inserted again with its original id.
+func RestoreTransactionVersion(historyId, userId, changedBy int64) (*models.Transaction, error) {
+	connection, err := pgx.Connect(connectionStringConfig)
+	if err != nil {
+		fmt.Println("Connection open error:", err)
+		return &models.Transaction{}, fmt.Errorf("DB not connected")
+	}
+	defer func() {
+		err := connection.Close()
+		if err != nil {
+			fmt.Println("Connection close error:", err)
+		}
+	}()
+	tx, err := connection.Begin()
+	if err != nil {
+		return &models.Transaction{}, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	entry, err := scanHistoryEntry(tx.QueryRow(getHistoryEntry, historyId, userId))
+	if err == pgx.ErrNoRows {
+		return &models.Transaction{}, ErrNotFound
+	}
+	if err != nil {
+		return &models.Transaction{}, err
+	}
+	snapshot := entry.Snapshot()
+	if snapshot == nil {
+		return &models.Transaction{}, fmt.Errorf("history entry has no transaction state")
+	}
+	snapshot.Id = entry.TransactionId
+	_, err = tx.Exec(untrashTransaction, entry.TransactionId, userId)
+	if err != nil {
+		return &models.Transaction{}, err
+	}
+	current, err := lockTransaction(tx, entry.TransactionId, userId)
+	if err != nil {
+		return &models.Transaction{}, err
+	}
+	if current != nil {
+		snapshot.Version = current.Version + 1
+		_, err = tx.Exec(updateTransaction,
+			snapshot.Amount,
+			snapshot.SpentAt,
+			snapshot.Note,
+			snapshot.CategoryId,
+			snapshot.Id,
+			userId)
+	} else {
+		snapshot.Version = 1
+		_, err = tx.Exec(insertTransactionWithId,
+			snapshot.Id,
+			fmt.Sprintf("$%f", snapshot.Amount),
+			snapshot.SpentAt,
+			snapshot.Note,
+			snapshot.CategoryId,
+			userId)
+	}
+	if err != nil {
+		return &models.Transaction{}, err
+	}
+	err = recordHistory(tx, snapshot.Id, userId, changedBy, models.RestoreAction, current, snapshot)
+	if err != nil {
+		return &models.Transaction{}, err
+	}
+	err = tx.Commit()
+	if err != nil {
+		return &models.Transaction{}, err
+	}
+	return snapshot, nil
+}
+
+// insertTransactionTx inserts the transaction inside tx, records its creation and returns the stored row.
+// ErrDuplicate is returned when the user already has a transaction with the same ExternalId.
+func insertTransactionTx(tx *pgx.Tx, transaction *models.Transaction, userId, changedBy int64) (*models.Transaction, error) {
+	created := *transaction
+	err := tx.QueryRow(insertReturningTransaction,
+		fmt.Sprintf("$%f", transaction.Amount),
+		transaction.SpentAt,
+		transaction.Note,
+		transaction.CategoryId,
+		userId,
+		transaction.ExternalId).Scan(&created.Id, &created.Version)
+	if err == pgx.ErrNoRows {
+		return nil, ErrDuplicate
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = recordHistory(tx, created.Id, userId, changedBy, models.CreateAction, nil, &created)
+	if err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// lockTransaction returns nil without an error when the user has no such transaction.
+func lockTransaction(tx *pgx.Tx, transactionId, userId int64) (*models.Transaction, error) {
+	transaction := models.Transaction{}
+	err := tx.QueryRow(selectTransactionForLock, transactionId, userId).Scan(&transaction.Id, &transaction.Amount, &transaction.SpentAt, &transaction.Note, &transaction.CategoryId, &transaction.Version)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &transaction, nil
+}
+
+// recordHistory adds the change of a transaction of userId made by changedBy, who is a user other
+// than the owner when acting on their data, or serverChange.
+func recordHistory(tx *pgx.Tx, transactionId, userId, changedBy int64, action string, before, after *models.Transaction) error {
+	beforeJson, err := marshalSnapshot(before)
+	if err != nil {
+		return err
+	}
+	afterJson, err := marshalSnapshot(after)
+	if err != nil {
+		return err
+	}
+	var author *int64
+	if changedBy != serverChange {
+		author = &changedBy
+	}
+	_, err = tx.Exec(insertHistoryEntry,
+		transactionId,
+		userId,
+		action,
+		author,
+		beforeJson,
+		afterJson)
+	if err != nil {
+		return err
+	}
+	// Every change of a transaction is recorded here, so this is the one place that announces them.
+	if event := transactionEvent(userId, action, before, after); event != nil {
+		return publishEvent(tx, event)
+	}
+	return nil
+}
+
+func marshalSnapshot(transaction *models.Transaction) (*string, error) {
+	if transaction == nil {
+		return nil, nil
+	}
+	bytes, err := json.Marshal(transaction)
+	if err != nil {
+		return nil, err
+	}
+	snapshot := string(bytes)
+	return &snapshot, nil
+}
+
+func unmarshalSnapshot(snapshot *string) (*models.Transaction, error) {
+	if snapshot == nil {
+		return nil, nil
+	}
+	transaction := models.Transaction{}
+	err := json.Unmarshal([]byte(*snapshot), &transaction)
+	if err != nil {
+		return nil, err
+	}
+	return &transaction, nil
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanHistoryEntry(row rowScanner) (*models.TransactionHistoryEntry, error) {
+	entry := models.TransactionHistoryEntry{}
+	var before, after *string
+	err := row.Scan(&entry.Id, &entry.TransactionId, &entry.Action, &entry.ChangedBy, &entry.ChangedAt, &before, &after)
+	if err != nil {
+		return nil, err
+	}
+	entry.Before, err = unmarshalSnapshot(before)
+	if err != nil {
+		return nil, err
+	}
+	entry.After, err = unmarshalSnapshot(after)
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
@@ -0,0 +1,110 @@
+package storage
+
+import (
+	"fmt"
+	"spendon/models"
+	"time"
+
+	"github.com/jackc/pgx"
+)
+
+const (
+	reserveIdempotencyKey       = "INSERT INTO idempotencykeys (userid, key, requesthash, createdat) VALUES ($1, $2, $3, now()) ON CONFLICT (userid, key) DO UPDATE SET requesthash=$3, createdat=now(), statuscode=NULL, response=NULL WHERE idempotencykeys.createdat<now()-$4::float8*interval '1 second'"
+	getIdempotencyKey           = "SELECT requesthash, statuscode, response FROM idempotencykeys WHERE userid=$1 and key=$2"
+	completeIdempotencyKey      = "UPDATE idempotencykeys SET statuscode=$3, response=$4 WHERE userid=$1 and key=$2"
+	releaseIdempotencyKey       = "DELETE FROM idempotencykeys WHERE userid=$1 and key=$2 and statuscode IS NULL"
+	purgeExpiredIdempotencyKeys = "DELETE FROM idempotencykeys WHERE createdat<now()-$1::float8*interval '1 second'"
+)
+
+// ReserveIdempotencyKey returns true when the key is new (or older than ttl) and the request should be
+// processed. Otherwise it returns the stored response, which is nil while the first request is in progress,
+// or ErrKeyReused when the key was stored for a request with another requestHash. The age is measured by
+// the database clock, which also stamped the key.
+func ReserveIdempotencyKey(userId int64, key, requestHash string, ttl time.Duration) (*models.IdempotentResponse, bool, error) {
+	connection, err := pgx.Connect(connectionStringConfig)
+	if err != nil {
+		fmt.Println("Connection open error:", err)
+		return nil, false, fmt.Errorf("DB not connected")
+	}
+	defer func() {
+		err := connection.Close()
+		if err != nil {
+			fmt.Println("Connection close error:", err)
+		}
+	}()
+	result, err := connection.Exec(reserveIdempotencyKey, userId, key, requestHash, ttl.Seconds())
+	if err != nil {
+		return nil, false, err
+	}
+	if result.RowsAffected() == 1 {
+		return nil, true, nil
+	}
+	var storedHash *string
+	var statusCode *int32
+	var response *string
+	err = connection.QueryRow(getIdempotencyKey, userId, key).Scan(&storedHash, &statusCode, &response)
+	if err != nil {
+		return nil, false, err
+	}
+	// Keys stored before the hash was recorded have none and are trusted.
+	if storedHash != nil && *storedHash != requestHash {
+		return nil, false, ErrKeyReused
+	}
+	if statusCode == nil || response == nil {
+		return nil, false, nil
+	}
+	return &models.IdempotentResponse{StatusCode: int(*statusCode), Body: *response}, false, nil
+}
+
+func CompleteIdempotencyKey(userId int64, key string, response *models.IdempotentResponse) error {
+	connection, err := pgx.Connect(connectionStringConfig)
+	if err != nil {
+		fmt.Println("Connection open error:", err)
+		return fmt.Errorf("DB not connected")
+	}
+	defer func() {
+		err := connection.Close()
+		if err != nil {
+			fmt.Println("Connection close error:", err)
+		}
+	}()
+	_, err = connection.Exec(completeIdempotencyKey, userId, key, response.StatusCode, response.Body)
+	return err
+}
+
+// ReleaseIdempotencyKey forgets a reserved key whose request failed, so the client can retry it.
+func ReleaseIdempotencyKey(userId int64, key string) error {
+	connection, err := pgx.Connect(connectionStringConfig)
+	if err != nil {
+		fmt.Println("Connection open error:", err)
+		return fmt.Errorf("DB not connected")
+	}
+	defer func() {
+		err := connection.Close()
+		if err != nil {
+			fmt.Println("Connection close error:", err)
+		}
+	}()
+	_, err = connection.Exec(releaseIdempotencyKey, userId, key)
+	return err
+}
+
+// PurgeExpiredIdempotencyKeys removes the keys of every user that are older than ttl.
+func PurgeExpiredIdempotencyKeys(ttl time.Duration) (int64, error) {
+	connection, err := pgx.Connect(connectionStringConfig)
+	if err != nil {
+		fmt.Println("Connection open error:", err)
+		return 0, fmt.Errorf("DB not connected")
+	}
+	defer func() {
+		err := connection.Close()
+		if err != nil {
+			fmt.Println("Connection close error:", err)
+		}
+	}()
+	result, err := connection.Exec(purgeExpiredIdempotencyKeys, ttl.Seconds())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
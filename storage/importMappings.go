@@ -0,0 +1,127 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"spendon/models"
+
+	"github.com/jackc/pgx"
+)
+
+const (
+	upsertImportMapping      = "INSERT INTO importmappings (name, mapping, userid) VALUES ($1, $2, $3) ON CONFLICT (userid, name) DO UPDATE SET mapping=excluded.mapping"
+	selectImportMappings     = "SELECT id, name, mapping FROM importmappings"
+	getImportMappingsForUser = selectImportMappings + " WHERE userid=$1 ORDER BY name"
+	getImportMappingById     = selectImportMappings + " WHERE id=$1 and userid=$2"
+	removeImportMapping      = "DELETE FROM importmappings WHERE id=$1 and userid=$2"
+)
+
+// SaveImportMapping stores the mapping under its name, replacing the previous one with the same name.
+func SaveImportMapping(savedMapping *models.SavedImportMapping, userId int64) error {
+	connection, err := pgx.Connect(connectionStringConfig)
+	if err != nil {
+		fmt.Println("Connection open error:", err)
+		return fmt.Errorf("DB not connected")
+	}
+	defer func() {
+		err := connection.Close()
+		if err != nil {
+			fmt.Println("Connection close error:", err)
+		}
+	}()
+	mapping, err := json.Marshal(savedMapping.Mapping)
+	if err != nil {
+		return err
+	}
+	_, err = connection.Exec(upsertImportMapping, savedMapping.Name, string(mapping), userId)
+	if err != nil {
+		fmt.Println(err)
+		return err
+	}
+	return nil
+}
+
+func GetImportMappings(userId int64) (models.SavedImportMappings, error) {
+	connection, err := pgx.Connect(connectionStringConfig)
+	if err != nil {
+		fmt.Println("Connection open error:", err)
+		return nil, fmt.Errorf("DB not connected")
+	}
+	defer func() {
+		err := connection.Close()
+		if err != nil {
+			fmt.Println("Connection close error:", err)
+		}
+	}()
+	rows, err := connection.Query(getImportMappingsForUser, userId)
+	if err != nil {
+		fmt.Println(err)
+		return nil, err
+	}
+	defer rows.Close()
+	savedMappings := make(models.SavedImportMappings, 0)
+	for rows.Next() {
+		savedMapping, err := scanImportMapping(rows)
+		if err != nil {
+			fmt.Println(err)
+			return savedMappings, err
+		}
+		savedMappings = append(savedMappings, *savedMapping)
+	}
+	return savedMappings, rows.Err()
+}
+
+func GetImportMapping(mappingId, userId int64) (*models.SavedImportMapping, error) {
+	connection, err := pgx.Connect(connectionStringConfig)
+	if err != nil {
+		fmt.Println("Connection open error:", err)
+		return nil, fmt.Errorf("DB not connected")
+	}
+	defer func() {
+		err := connection.Close()
+		if err != nil {
+			fmt.Println("Connection close error:", err)
+		}
+	}()
+	savedMapping, err := scanImportMapping(connection.QueryRow(getImportMappingById, mappingId, userId))
+	if err == pgx.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	return savedMapping, err
+}
+
+func RemoveImportMapping(mappingId, userId int64) error {
+	connection, err := pgx.Connect(connectionStringConfig)
+	if err != nil {
+		fmt.Println("Connection open error:", err)
+		return fmt.Errorf("DB not connected")
+	}
+	defer func() {
+		err := connection.Close()
+		if err != nil {
+			fmt.Println("Connection close error:", err)
+		}
+	}()
+	result, err := connection.Exec(removeImportMapping, mappingId, userId)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func scanImportMapping(row rowScanner) (*models.SavedImportMapping, error) {
+	savedMapping := models.SavedImportMapping{}
+	var mapping string
+	err := row.Scan(&savedMapping.Id, &savedMapping.Name, &mapping)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal([]byte(mapping), &savedMapping.Mapping)
+	if err != nil {
+		return nil, err
+	}
+	return &savedMapping, nil
+}
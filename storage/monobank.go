@@ -0,0 +1,53 @@
+package storage
+
+import (
+	"fmt"
+	"spendon/models"
+
+	"github.com/jackc/pgx"
+)
+
+const (
+	upsertMonobankWebhook = "INSERT INTO monobankwebhooks (userid, secret) VALUES ($1, $2) ON CONFLICT (userid) DO UPDATE SET secret=excluded.secret"
+	getMonobankWebhook    = "SELECT u.id, u.login, w.secret FROM monobankwebhooks w JOIN users u ON u.id=w.userid WHERE w.userid=$1"
+)
+
+// SaveMonobankWebhookSecret replaces the secret that identifies the user in Monobank webhook URLs.
+func SaveMonobankWebhookSecret(userId int64, secret string) error {
+	connection, err := pgx.Connect(connectionStringConfig)
+	if err != nil {
+		fmt.Println("Connection open error:", err)
+		return fmt.Errorf("DB not connected")
+	}
+	defer func() {
+		err := connection.Close()
+		if err != nil {
+			fmt.Println("Connection close error:", err)
+		}
+	}()
+	_, err = connection.Exec(upsertMonobankWebhook, userId, secret)
+	return err
+}
+
+// GetMonobankWebhook returns the user and the secret of their webhook. The secret is compared by
+// the caller, so the lookup does not depend on it.
+func GetMonobankWebhook(userId int64) (*models.DbLogin, string, error) {
+	dbLogin := models.DbLogin{}
+	connection, err := pgx.Connect(connectionStringConfig)
+	if err != nil {
+		fmt.Println("Connection open error:", err)
+		return &dbLogin, "", fmt.Errorf("DB not connected")
+	}
+	defer func() {
+		err := connection.Close()
+		if err != nil {
+			fmt.Println("Connection close error:", err)
+		}
+	}()
+	secret := ""
+	err = connection.QueryRow(getMonobankWebhook, userId).Scan(&dbLogin.Id, &dbLogin.Login, &secret)
+	if err == pgx.ErrNoRows {
+		return &dbLogin, "", ErrNotFound
+	}
+	return &dbLogin, secret, err
+}
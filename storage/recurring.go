@@ -0,0 +1,261 @@
+package storage
+
+import (
+	"fmt"
+	"spendon/models"
+	"time"
+
+	"github.com/jackc/pgx"
+)
+
+const (
+	insertRecurringRule      = "INSERT INTO recurringrules (amount, note, categoryid, frequency, interval, dayofmonth, startdate, enddate, count, userid) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)"
+	selectRecurringRules     = "SELECT id, userid, amount::numeric, note, categoryid, frequency, interval, dayofmonth, startdate, enddate, count, (SELECT MAX(occurrencedate) FROM recurringruns WHERE ruleid=recurringrules.id) FROM recurringrules"
+	getRecurringRulesForUser = selectRecurringRules + " WHERE userid=$1 ORDER BY id"
+	getRecurringRuleById     = selectRecurringRules + " WHERE id=$1 and userid=$2"
+	removeRecurringRule      = "DELETE FROM recurringrules WHERE id=$1 and userid=$2"
+	getRecurringExceptions   = "SELECT ruleid, occurrencedate, skip, amount::numeric, note, categoryid FROM recurringexceptions WHERE ruleid=$1"
+	upsertRecurringException = "INSERT INTO recurringexceptions (ruleid, occurrencedate, skip, amount, note, categoryid) SELECT $1, $2, $3, $4, $5, $6 WHERE EXISTS (SELECT 1 FROM recurringrules WHERE id=$1 and userid=$7) ON CONFLICT (ruleid, occurrencedate) DO UPDATE SET skip=excluded.skip, amount=excluded.amount, note=excluded.note, categoryid=excluded.categoryid"
+	insertRecurringRun       = "INSERT INTO recurringruns (ruleid, occurrencedate) VALUES ($1, $2) ON CONFLICT (ruleid, occurrencedate) DO NOTHING"
+	updateRecurringRun       = "UPDATE recurringruns SET transactionid=$1 WHERE ruleid=$2 and occurrencedate=$3"
+)
+
+func InsertRecurringRule(rule *models.RecurringRule, userId int64) error {
+	connection, err := pgx.Connect(connectionStringConfig)
+	if err != nil {
+		fmt.Println("Connection open error:", err)
+		return fmt.Errorf("DB not connected")
+	}
+	defer func() {
+		err := connection.Close()
+		if err != nil {
+			fmt.Println("Connection close error:", err)
+		}
+	}()
+	_, err = connection.Exec(insertRecurringRule,
+		fmt.Sprintf("$%f", rule.Amount),
+		rule.Note,
+		rule.CategoryId,
+		rule.Frequency,
+		rule.Interval,
+		rule.DayOfMonth,
+		rule.StartDate,
+		rule.EndDate,
+		rule.Count,
+		userId)
+	if err != nil {
+		fmt.Println(err)
+		return err
+	}
+	return nil
+}
+
+func GetRecurringRules(userId int64) (models.RecurringRules, error) {
+	connection, err := pgx.Connect(connectionStringConfig)
+	if err != nil {
+		fmt.Println("Connection open error:", err)
+		return nil, fmt.Errorf("DB not connected")
+	}
+	defer func() {
+		err := connection.Close()
+		if err != nil {
+			fmt.Println("Connection close error:", err)
+		}
+	}()
+	return queryRecurringRules(connection, getRecurringRulesForUser, userId)
+}
+
+// GetAllRecurringRules is used by the scheduler and returns rules of every user.
+func GetAllRecurringRules() (models.RecurringRules, error) {
+	connection, err := pgx.Connect(connectionStringConfig)
+	if err != nil {
+		fmt.Println("Connection open error:", err)
+		return nil, fmt.Errorf("DB not connected")
+	}
+	defer func() {
+		err := connection.Close()
+		if err != nil {
+			fmt.Println("Connection close error:", err)
+		}
+	}()
+	return queryRecurringRules(connection, selectRecurringRules)
+}
+
+func GetRecurringRule(ruleId, userId int64) (*models.RecurringRule, error) {
+	connection, err := pgx.Connect(connectionStringConfig)
+	if err != nil {
+		fmt.Println("Connection open error:", err)
+		return &models.RecurringRule{}, fmt.Errorf("DB not connected")
+	}
+	defer func() {
+		err := connection.Close()
+		if err != nil {
+			fmt.Println("Connection close error:", err)
+		}
+	}()
+	rules, err := queryRecurringRules(connection, getRecurringRuleById, ruleId, userId)
+	if err != nil {
+		return &models.RecurringRule{}, err
+	}
+	if len(rules) == 0 {
+		return &models.RecurringRule{}, ErrNotFound
+	}
+	return &rules[0], nil
+}
+
+// RemoveRecurringRule returns ErrNotFound when the user has no such rule.
+func RemoveRecurringRule(ruleId, userId int64) error {
+	connection, err := pgx.Connect(connectionStringConfig)
+	if err != nil {
+		fmt.Println("Connection open error:", err)
+		return fmt.Errorf("DB not connected")
+	}
+	defer func() {
+		err := connection.Close()
+		if err != nil {
+			fmt.Println("Connection close error:", err)
+		}
+	}()
+	result, err := connection.Exec(removeRecurringRule, ruleId, userId)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func GetRecurringExceptions(ruleId int64) (models.RecurringExceptions, error) {
+	connection, err := pgx.Connect(connectionStringConfig)
+	if err != nil {
+		fmt.Println("Connection open error:", err)
+		return nil, fmt.Errorf("DB not connected")
+	}
+	defer func() {
+		err := connection.Close()
+		if err != nil {
+			fmt.Println("Connection close error:", err)
+		}
+	}()
+	rows, err := connection.Query(getRecurringExceptions, ruleId)
+	if err != nil {
+		fmt.Println(err)
+		return nil, err
+	}
+	defer rows.Close()
+	exceptions := make(models.RecurringExceptions, 0)
+	for rows.Next() {
+		exception := models.RecurringException{}
+		err := rows.Scan(&exception.RuleId, &exception.OccurrenceDate, &exception.Skip, &exception.Amount, &exception.Note, &exception.CategoryId)
+		if err != nil {
+			fmt.Println(err)
+			return exceptions, err
+		}
+		exceptions = append(exceptions, exception)
+	}
+	return exceptions, rows.Err()
+}
+
+// UpsertRecurringException skips or modifies a single occurrence of a rule owned by the user.
+func UpsertRecurringException(exception *models.RecurringException, userId int64) error {
+	connection, err := pgx.Connect(connectionStringConfig)
+	if err != nil {
+		fmt.Println("Connection open error:", err)
+		return fmt.Errorf("DB not connected")
+	}
+	defer func() {
+		err := connection.Close()
+		if err != nil {
+			fmt.Println("Connection close error:", err)
+		}
+	}()
+	var amount *string
+	if exception.Amount != nil {
+		formattedAmount := fmt.Sprintf("$%f", *exception.Amount)
+		amount = &formattedAmount
+	}
+	result, err := connection.Exec(upsertRecurringException,
+		exception.RuleId,
+		exception.OccurrenceDate,
+		exception.Skip,
+		amount,
+		exception.Note,
+		exception.CategoryId,
+		userId)
+	if err != nil {
+		fmt.Println(err)
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// CreateRecurringOccurrence records the occurrence as generated and inserts its
+// transaction in one DB transaction. An occurrence that was already generated
+// is left untouched, so the scheduler may call it any number of times.
+func CreateRecurringOccurrence(rule *models.RecurringRule, date time.Time, exception *models.RecurringException) (bool, error) {
+	connection, err := pgx.Connect(connectionStringConfig)
+	if err != nil {
+		fmt.Println("Connection open error:", err)
+		return false, fmt.Errorf("DB not connected")
+	}
+	defer func() {
+		err := connection.Close()
+		if err != nil {
+			fmt.Println("Connection close error:", err)
+		}
+	}()
+	tx, err := connection.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	result, err := tx.Exec(insertRecurringRun, rule.Id, date)
+	if err != nil {
+		return false, err
+	}
+	if result.RowsAffected() == 0 {
+		return false, nil
+	}
+	transaction, skipped := rule.TransactionFor(date, exception)
+	if !skipped {
+		created, err := insertTransactionTx(tx, &transaction, rule.UserId, serverChange)
+		if err != nil {
+			return false, err
+		}
+		_, err = tx.Exec(updateRecurringRun, created.Id, rule.Id, date)
+		if err != nil {
+			return false, err
+		}
+	}
+	err = tx.Commit()
+	if err != nil {
+		return false, err
+	}
+	return !skipped, nil
+}
+
+func queryRecurringRules(connection *pgx.Conn, query string, args ...interface{}) (models.RecurringRules, error) {
+	rows, err := connection.Query(query, args...)
+	if err != nil {
+		fmt.Println(err)
+		return nil, err
+	}
+	defer rows.Close()
+	rules := make(models.RecurringRules, 0)
+	for rows.Next() {
+		rule := models.RecurringRule{}
+		err := rows.Scan(&rule.Id, &rule.UserId, &rule.Amount, &rule.Note, &rule.CategoryId, &rule.Frequency, &rule.Interval, &rule.DayOfMonth, &rule.StartDate, &rule.EndDate, &rule.Count, &rule.LastGenerated)
+		if err != nil {
+			fmt.Println(err)
+			return rules, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, rows.Err()
+}
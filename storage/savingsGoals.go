@@ -0,0 +1,146 @@
+package storage
+
+import (
+	"fmt"
+	"spendon/models"
+	"time"
+
+	"github.com/jackc/pgx"
+)
+
+const (
+	insertSavingsGoal       = "INSERT INTO savingsgoals (name, targetamount, targetdate, categoryid, startdate, userid) VALUES ($1, $2, $3, $4, $5, $6)"
+	selectSavingsGoals      = "SELECT id, name, targetamount::numeric, targetdate, categoryid, startdate FROM savingsgoals"
+	getSavingsGoalsForUser  = selectSavingsGoals + " WHERE userid=$1 ORDER BY targetdate"
+	getSavingsGoalById      = selectSavingsGoals + " WHERE id=$1 and userid=$2"
+	removeSavingsGoal       = "DELETE FROM savingsgoals WHERE id=$1 and userid=$2"
+	getMonthlyContributions = "SELECT date_trunc('month', spentat), SUM(amount)::numeric FROM transactions WHERE userid=$1 and categoryid=$2 and spentat>=$3 and deletedat IS NULL GROUP BY 1 ORDER BY 1"
+)
+
+func InsertSavingsGoal(goal *models.SavingsGoal, userId int64) error {
+	connection, err := pgx.Connect(connectionStringConfig)
+	if err != nil {
+		fmt.Println("Connection open error:", err)
+		return fmt.Errorf("DB not connected")
+	}
+	defer func() {
+		err := connection.Close()
+		if err != nil {
+			fmt.Println("Connection close error:", err)
+		}
+	}()
+	startDate := goal.StartDate
+	if startDate.IsZero() {
+		startDate = time.Now().UTC()
+	}
+	_, err = connection.Exec(insertSavingsGoal,
+		goal.Name,
+		fmt.Sprintf("$%f", goal.TargetAmount),
+		goal.TargetDate,
+		goal.CategoryId,
+		startDate,
+		userId)
+	if err != nil {
+		fmt.Println(err)
+		return err
+	}
+	return nil
+}
+
+func GetSavingsGoals(userId int64) (models.SavingsGoals, error) {
+	connection, err := pgx.Connect(connectionStringConfig)
+	if err != nil {
+		fmt.Println("Connection open error:", err)
+		return nil, fmt.Errorf("DB not connected")
+	}
+	defer func() {
+		err := connection.Close()
+		if err != nil {
+			fmt.Println("Connection close error:", err)
+		}
+	}()
+	rows, err := connection.Query(getSavingsGoalsForUser, userId)
+	if err != nil {
+		fmt.Println(err)
+		return nil, err
+	}
+	defer rows.Close()
+	goals := make(models.SavingsGoals, 0)
+	for rows.Next() {
+		goal := models.SavingsGoal{}
+		err := rows.Scan(&goal.Id, &goal.Name, &goal.TargetAmount, &goal.TargetDate, &goal.CategoryId, &goal.StartDate)
+		if err != nil {
+			fmt.Println(err)
+			return goals, err
+		}
+		goals = append(goals, goal)
+	}
+	return goals, rows.Err()
+}
+
+// RemoveSavingsGoal returns ErrNotFound when the user has no such goal.
+func RemoveSavingsGoal(goalId, userId int64) error {
+	connection, err := pgx.Connect(connectionStringConfig)
+	if err != nil {
+		fmt.Println("Connection open error:", err)
+		return fmt.Errorf("DB not connected")
+	}
+	defer func() {
+		err := connection.Close()
+		if err != nil {
+			fmt.Println("Connection close error:", err)
+		}
+	}()
+	result, err := connection.Exec(removeSavingsGoal, goalId, userId)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// GetSavingsGoalProgress returns ErrNotFound when the user has no such goal.
+func GetSavingsGoalProgress(goalId, userId int64, now time.Time) (*models.SavingsGoalProgress, error) {
+	connection, err := pgx.Connect(connectionStringConfig)
+	if err != nil {
+		fmt.Println("Connection open error:", err)
+		return nil, fmt.Errorf("DB not connected")
+	}
+	defer func() {
+		err := connection.Close()
+		if err != nil {
+			fmt.Println("Connection close error:", err)
+		}
+	}()
+	goal := models.SavingsGoal{}
+	err = connection.QueryRow(getSavingsGoalById, goalId, userId).Scan(&goal.Id, &goal.Name, &goal.TargetAmount, &goal.TargetDate, &goal.CategoryId, &goal.StartDate)
+	if err == pgx.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	rows, err := connection.Query(getMonthlyContributions, userId, goal.CategoryId, goal.StartDate)
+	if err != nil {
+		fmt.Println(err)
+		return nil, err
+	}
+	defer rows.Close()
+	contributions := make(models.MonthlyContributions, 0)
+	for rows.Next() {
+		contribution := models.MonthlyContribution{}
+		err := rows.Scan(&contribution.Month, &contribution.Sum)
+		if err != nil {
+			fmt.Println(err)
+			return nil, err
+		}
+		contributions = append(contributions, contribution)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	progress := goal.Progress(now, contributions)
+	return &progress, nil
+}
@@ -0,0 +1,130 @@
+package storage
+
+import (
+	"fmt"
+	"spendon/models"
+	"time"
+
+	"github.com/jackc/pgx"
+)
+
+const (
+	getTrashedTransactions   = "SELECT id, amount::numeric, spentat::text, note, categoryid, version, deletedat FROM transactions WHERE userid=$1 and deletedat IS NOT NULL ORDER BY deletedat DESC"
+	restoreTrashed           = "UPDATE transactions SET deletedat=NULL WHERE id=ANY($1) and userid=$2 and deletedat IS NOT NULL RETURNING id, amount::numeric, spentat::text, note, categoryid, version, userid"
+	restoreAllTrashed        = "UPDATE transactions SET deletedat=NULL WHERE userid=$1 and deletedat IS NOT NULL RETURNING id, amount::numeric, spentat::text, note, categoryid, version, userid"
+	purgeTrashed             = "DELETE FROM transactions WHERE id=ANY($1) and userid=$2 and deletedat IS NOT NULL RETURNING id, amount::numeric, spentat::text, note, categoryid, version, userid"
+	purgeAllTrashed          = "DELETE FROM transactions WHERE userid=$1 and deletedat IS NOT NULL RETURNING id, amount::numeric, spentat::text, note, categoryid, version, userid"
+	purgeExpiredTransactions = "DELETE FROM transactions WHERE deletedat<now()-$1::float8*interval '1 second' RETURNING id, amount::numeric, spentat::text, note, categoryid, version, userid"
+)
+
+func GetTrashedTransactions(userId int64) (models.TrashedTransactions, error) {
+	connection, err := pgx.Connect(connectionStringConfig)
+	if err != nil {
+		fmt.Println("Connection open error:", err)
+		return nil, fmt.Errorf("DB not connected")
+	}
+	defer func() {
+		err := connection.Close()
+		if err != nil {
+			fmt.Println("Connection close error:", err)
+		}
+	}()
+	rows, err := connection.Query(getTrashedTransactions, userId)
+	if err != nil {
+		fmt.Println(err)
+		return nil, err
+	}
+	defer rows.Close()
+	trashedTransactions := make(models.TrashedTransactions, 0)
+	for rows.Next() {
+		trashedTransaction := models.TrashedTransaction{}
+		transaction := &trashedTransaction.Transaction
+		err := rows.Scan(&transaction.Id, &transaction.Amount, &transaction.SpentAt, &transaction.Note, &transaction.CategoryId, &transaction.Version, &trashedTransaction.DeletedAt)
+		if err != nil {
+			fmt.Println(err)
+			return trashedTransactions, err
+		}
+		trashedTransactions = append(trashedTransactions, trashedTransaction)
+	}
+	return trashedTransactions, rows.Err()
+}
+
+func RestoreFromTrash(trashRequest *models.TrashRequest, userId, changedBy int64) (int, error) {
+	if trashRequest.All {
+		return changeTrashed(models.RestoreAction, changedBy, restoreAllTrashed, userId)
+	}
+	return changeTrashed(models.RestoreAction, changedBy, restoreTrashed, trashRequest.TransactionIds, userId)
+}
+
+func PurgeTrash(trashRequest *models.TrashRequest, userId, changedBy int64) (int, error) {
+	if trashRequest.All {
+		return changeTrashed(models.PurgeAction, changedBy, purgeAllTrashed, userId)
+	}
+	return changeTrashed(models.PurgeAction, changedBy, purgeTrashed, trashRequest.TransactionIds, userId)
+}
+
+// PurgeExpiredTrash removes transactions of every user that have been in the trash for longer
+// than retention. The cutoff is taken from the database clock, which also set deletedat.
+func PurgeExpiredTrash(retention time.Duration) (int, error) {
+	return changeTrashed(models.PurgeAction, serverChange, purgeExpiredTransactions, retention.Seconds())
+}
+
+// changeTrashed runs a query returning the affected transactions and records the action of changedBy for each of them.
+func changeTrashed(action string, changedBy int64, query string, args ...interface{}) (int, error) {
+	connection, err := pgx.Connect(connectionStringConfig)
+	if err != nil {
+		fmt.Println("Connection open error:", err)
+		return 0, fmt.Errorf("DB not connected")
+	}
+	defer func() {
+		err := connection.Close()
+		if err != nil {
+			fmt.Println("Connection close error:", err)
+		}
+	}()
+	tx, err := connection.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		fmt.Println(err)
+		return 0, err
+	}
+	changedTransactions := make([]models.Transaction, 0)
+	owners := make([]int64, 0)
+	for rows.Next() {
+		transaction := models.Transaction{}
+		var ownerId int64
+		err := rows.Scan(&transaction.Id, &transaction.Amount, &transaction.SpentAt, &transaction.Note, &transaction.CategoryId, &transaction.Version, &ownerId)
+		if err != nil {
+			rows.Close()
+			return 0, err
+		}
+		changedTransactions = append(changedTransactions, transaction)
+		owners = append(owners, ownerId)
+	}
+	rows.Close()
+	if rows.Err() != nil {
+		return 0, rows.Err()
+	}
+	for idx := range changedTransactions {
+		transaction := &changedTransactions[idx]
+		if action == models.PurgeAction {
+			err = recordHistory(tx, transaction.Id, owners[idx], changedBy, action, transaction, nil)
+		} else {
+			err = recordHistory(tx, transaction.Id, owners[idx], changedBy, action, nil, transaction)
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+	err = tx.Commit()
+	if err != nil {
+		return 0, err
+	}
+	return len(changedTransactions), nil
+}
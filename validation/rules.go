@@ -0,0 +1,125 @@
+package validation
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"spendon/models"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const minPasswordLength = 8
+
+var builtinRules = map[string]Rule{
+	"required":  required,
+	"min":       minimum,
+	"max":       maximum,
+	"oneof":     oneOf,
+	"finite":    finite,
+	"timestamp": timestamp,
+	"password":  password,
+}
+
+func required(value reflect.Value, _ string) (string, error) {
+	if isEmpty(value) {
+		return "is required", nil
+	}
+	return "", nil
+}
+
+// minimum compares numbers by value and strings and slices by length.
+func minimum(value reflect.Value, param string) (string, error) {
+	limit := parseNumber(param)
+	switch value.Kind() {
+	case reflect.String:
+		if float64(utf8.RuneCountInString(value.String())) < limit {
+			return fmt.Sprintf("must be at least %s characters long", param), nil
+		}
+	case reflect.Slice, reflect.Array, reflect.Map:
+		if float64(value.Len()) < limit {
+			return fmt.Sprintf("must have at least %s items", param), nil
+		}
+	default:
+		if number, ok := numberOf(value); ok && number < limit {
+			return fmt.Sprintf("must be at least %s", param), nil
+		}
+	}
+	return "", nil
+}
+
+func maximum(value reflect.Value, param string) (string, error) {
+	limit := parseNumber(param)
+	switch value.Kind() {
+	case reflect.String:
+		if float64(utf8.RuneCountInString(value.String())) > limit {
+			return fmt.Sprintf("must be at most %s characters long", param), nil
+		}
+	case reflect.Slice, reflect.Array, reflect.Map:
+		if float64(value.Len()) > limit {
+			return fmt.Sprintf("must have at most %s items", param), nil
+		}
+	default:
+		if number, ok := numberOf(value); ok && number > limit {
+			return fmt.Sprintf("must be at most %s", param), nil
+		}
+	}
+	return "", nil
+}
+
+// oneOf takes the allowed values separated by spaces.
+func oneOf(value reflect.Value, param string) (string, error) {
+	allowed := strings.Fields(param)
+	actual := fmt.Sprint(value.Interface())
+	for _, option := range allowed {
+		if option == actual {
+			return "", nil
+		}
+	}
+	return "must be one of " + strings.Join(allowed, ", "), nil
+}
+
+func finite(value reflect.Value, _ string) (string, error) {
+	if number, ok := numberOf(value); ok && (math.IsNaN(number) || math.IsInf(number, 0)) {
+		return "must be a finite number", nil
+	}
+	return "", nil
+}
+
+func timestamp(value reflect.Value, _ string) (string, error) {
+	_, err := models.ParseSpentAt(value.String())
+	if err != nil {
+		return err.Error(), nil
+	}
+	return "", nil
+}
+
+// password needs a letter and a digit on top of the minimal length.
+func password(value reflect.Value, _ string) (string, error) {
+	text := value.String()
+	if utf8.RuneCountInString(text) < minPasswordLength {
+		return fmt.Sprintf("must be at least %d characters long", minPasswordLength), nil
+	}
+	hasLetter, hasDigit := false, false
+	for _, symbol := range text {
+		hasLetter = hasLetter || unicode.IsLetter(symbol)
+		hasDigit = hasDigit || unicode.IsDigit(symbol)
+	}
+	if !hasLetter || !hasDigit {
+		return "must contain a letter and a digit", nil
+	}
+	return "", nil
+}
+
+func numberOf(value reflect.Value) (float64, bool) {
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(value.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(value.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return value.Float(), true
+	}
+	return 0, false
+}
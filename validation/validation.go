@@ -0,0 +1,165 @@
+// Package validation checks request models against the rules declared in their `validate` struct tags.
+//
+// A tag lists comma separated rules, checked in order until the first failure:
+//
+//	Amount  float32 `validate:"required,finite,min=-1000000000,max=1000000000"`
+//	Note    string  `validate:"max=500"`
+//
+// A field without "required" is only checked when it is set. Nested structs and slices are
+// walked, and a model whose rules span several fields implements Checker.
+package validation
+
+import (
+	"fmt"
+	"reflect"
+	"spendon/models"
+	"strconv"
+	"strings"
+)
+
+// Rule checks one field against the rule parameter, the text after "=" in the tag. It returns
+// an empty message when the value passes and an error only when the check itself failed.
+type Rule func(value reflect.Value, param string) (string, error)
+
+// Checker is implemented by models with rules that do not fit a single field.
+type Checker interface {
+	Check() []models.FieldError
+}
+
+type Validator struct {
+	rules map[string]Rule
+}
+
+// New returns a validator with the built-in rules.
+func New() *Validator {
+	validator := &Validator{rules: make(map[string]Rule, len(builtinRules))}
+	for name, rule := range builtinRules {
+		validator.rules[name] = rule
+	}
+	return validator
+}
+
+// Register adds a rule or replaces one with the same name.
+func (validator *Validator) Register(name string, rule Rule) *Validator {
+	validator.rules[name] = rule
+	return validator
+}
+
+// Validate returns every failed rule of value, which is a struct, a slice of structs or a pointer to one.
+func (validator *Validator) Validate(value interface{}) ([]models.FieldError, error) {
+	fieldErrors := make([]models.FieldError, 0)
+	err := validator.walk(reflect.ValueOf(value), "", &fieldErrors)
+	return fieldErrors, err
+}
+
+func (validator *Validator) walk(value reflect.Value, path string, fieldErrors *[]models.FieldError) error {
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return nil
+		}
+		value = value.Elem()
+	}
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array:
+		for idx := 0; idx < value.Len(); idx++ {
+			err := validator.walk(value.Index(idx), fmt.Sprintf("%s[%d]", path, idx), fieldErrors)
+			if err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		structType := value.Type()
+		for idx := 0; idx < structType.NumField(); idx++ {
+			field := structType.Field(idx)
+			if field.PkgPath != "" {
+				continue
+			}
+			fieldPath := joinPath(path, field.Name)
+			failed, err := validator.checkField(value.Field(idx), field.Tag.Get("validate"), fieldPath, fieldErrors)
+			if err != nil {
+				return err
+			}
+			if !failed {
+				err = validator.walk(value.Field(idx), fieldPath, fieldErrors)
+				if err != nil {
+					return err
+				}
+			}
+		}
+		if checker, ok := addressable(value).Interface().(Checker); ok {
+			for _, fieldError := range checker.Check() {
+				fieldError.Field = joinPath(path, fieldError.Field)
+				*fieldErrors = append(*fieldErrors, fieldError)
+			}
+		}
+	}
+	return nil
+}
+
+// checkField applies the rules of tag and reports whether one of them failed.
+func (validator *Validator) checkField(value reflect.Value, tag, path string, fieldErrors *[]models.FieldError) (bool, error) {
+	if tag == "" || tag == "-" {
+		return false, nil
+	}
+	// Rules of an optional field apply to its value; a nil pointer counts as empty.
+	if value.Kind() == reflect.Ptr && !value.IsNil() {
+		value = value.Elem()
+	}
+	for _, declaration := range strings.Split(tag, ",") {
+		name, param := declaration, ""
+		if idx := strings.Index(declaration, "="); idx >= 0 {
+			name, param = declaration[:idx], declaration[idx+1:]
+		}
+		if name != "required" && isEmpty(value) {
+			continue
+		}
+		rule, ok := validator.rules[name]
+		if !ok {
+			return false, fmt.Errorf("validation rule %q of %s is not registered", name, path)
+		}
+		message, err := rule(value, param)
+		if err != nil {
+			return false, err
+		}
+		if message != "" {
+			*fieldErrors = append(*fieldErrors, models.FieldError{Field: path, Message: message})
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	if strings.HasPrefix(name, "[") {
+		return path + name
+	}
+	return path + "." + name
+}
+
+// addressable lets Checker be implemented with a pointer receiver.
+func addressable(value reflect.Value) reflect.Value {
+	if value.CanAddr() {
+		return value.Addr()
+	}
+	copied := reflect.New(value.Type())
+	copied.Elem().Set(value)
+	return copied
+}
+
+func isEmpty(value reflect.Value) bool {
+	if value.Kind() == reflect.String {
+		return strings.TrimSpace(value.String()) == ""
+	}
+	return value.IsZero()
+}
+
+func parseNumber(param string) float64 {
+	number, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		panic(fmt.Sprintf("validation parameter %q is not a number", param))
+	}
+	return number
+}
@@ -0,0 +1,154 @@
+package validation
+
+import (
+	"math"
+	"reflect"
+	"spendon/models"
+	"testing"
+)
+
+func TestRules(t *testing.T) {
+	tests := []struct {
+		rule  string
+		param string
+		value interface{}
+		valid bool
+	}{
+		{"required", "", "note", true},
+		{"required", "", "  ", false},
+		{"required", "", 0, false},
+		{"required", "", int32(3), true},
+		{"required", "", float32(0), false},
+
+		{"min", "2", 2, true},
+		{"min", "2", 1, false},
+		{"min", "-5", float32(-5.5), false},
+		{"min", "3", "abc", true},
+		{"min", "3", "ab", false},
+		{"min", "3", "äöü", true},
+		{"min", "1", []int{}, false},
+		{"min", "1", []int{1}, true},
+
+		{"max", "10", 10, true},
+		{"max", "10", int64(11), false},
+		{"max", "3", "abcd", false},
+		{"max", "3", "äöü", true},
+		{"max", "1", []string{"a", "b"}, false},
+
+		{"oneof", "monthly weekly", "weekly", true},
+		{"oneof", "monthly weekly", "daily", false},
+		{"oneof", "0 1 2", 2, true},
+		{"oneof", "0 1 2", 3, false},
+
+		{"finite", "", float32(1.5), true},
+		{"finite", "", math.NaN(), false},
+		{"finite", "", math.Inf(1), false},
+		{"finite", "", float32(math.Inf(-1)), false},
+
+		{"timestamp", "", "2024-03-01T10:15:00", true},
+		{"timestamp", "", "2024-03-01 10:15:00", true},
+		{"timestamp", "", "2024-03-01 10:15:00.123456", true},
+		{"timestamp", "", "2024-03-01T10:15", true},
+		{"timestamp", "", "2024-03-01T10:15:00+02:00", true},
+		{"timestamp", "", "2024-03-01", true},
+		{"timestamp", "", "01.03.2024", false},
+		{"timestamp", "", "2024-13-01", false},
+
+		{"password", "", "secret12", true},
+		{"password", "", "secret1", false},
+		{"password", "", "secretpassword", false},
+		{"password", "", "12345678", false},
+	}
+	for _, test := range tests {
+		rule := builtinRules[test.rule]
+		message, err := rule(reflect.ValueOf(test.value), test.param)
+		if err != nil {
+			t.Errorf("%s=%s %#v: %v", test.rule, test.param, test.value, err)
+			continue
+		}
+		if (message == "") != test.valid {
+			t.Errorf("%s=%s %#v: got message %q, want valid %v", test.rule, test.param, test.value, message, test.valid)
+		}
+	}
+}
+
+type validated struct {
+	Name     string  `validate:"required,max=5"`
+	Optional string  `validate:"min=3"`
+	Amount   float32 `validate:"required,finite"`
+	Items    []validatedItem
+}
+
+type validatedItem struct {
+	Kind string `validate:"oneof=a b"`
+}
+
+func (item *validatedItem) Check() []models.FieldError {
+	if item.Kind == "b" {
+		return []models.FieldError{{Field: "Kind", Message: "b is taken"}}
+	}
+	return nil
+}
+
+func TestValidateReportsFieldPaths(t *testing.T) {
+	fieldErrors, err := New().Validate(&validated{
+		Name:   "too long",
+		Amount: float32(math.NaN()),
+		Items:  []validatedItem{{Kind: "a"}, {Kind: "c"}, {Kind: "b"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"Name", "Amount", "Items[1].Kind", "Items[2].Kind"}
+	if len(fieldErrors) != len(want) {
+		t.Fatalf("got %+v, want fields %v", fieldErrors, want)
+	}
+	for idx, field := range want {
+		if fieldErrors[idx].Field != field {
+			t.Errorf("got field %s, want %s", fieldErrors[idx].Field, field)
+		}
+	}
+}
+
+func TestValidateFailsOnUnknownRule(t *testing.T) {
+	_, err := New().Validate(models.Transaction{Amount: 1, SpentAt: "2024-03-01", CategoryId: 1})
+	if err == nil {
+		t.Error("the unregistered category rule was ignored")
+	}
+}
+
+// TestServerTransactionPassesValidation sends back a transaction as storage returns it, with
+// SpentAt read as spentat::text.
+func TestServerTransactionPassesValidation(t *testing.T) {
+	validator := New().Register("category", func(value reflect.Value, _ string) (string, error) {
+		return "", nil
+	})
+	stored := models.Transaction{Id: 12, Amount: -42.5, SpentAt: "2024-03-01 10:15:00", Note: "groceries", CategoryId: 3, Version: 2}
+	fieldErrors, err := validator.Validate(&stored)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fieldErrors) != 0 {
+		t.Errorf("got %+v", fieldErrors)
+	}
+}
+
+func TestRulesApplyToPointedValues(t *testing.T) {
+	type optional struct {
+		Note *string `validate:"max=3"`
+		Day  *int    `validate:"required"`
+	}
+	long := "four"
+	fieldErrors, err := New().Validate(&optional{Note: &long})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fieldErrors) != 2 || fieldErrors[0].Field != "Note" || fieldErrors[1].Field != "Day" {
+		t.Errorf("got %+v, want Note and Day", fieldErrors)
+	}
+	short, day := "one", 3
+	fieldErrors, _ = New().Validate(&optional{Note: &short, Day: &day})
+	if len(fieldErrors) != 0 {
+		t.Errorf("got %+v", fieldErrors)
+	}
+}